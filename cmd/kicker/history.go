@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/audit"
+	"github.com/curlymon/kicker/pkg/conf"
+)
+
+// history implements the `kicker history` subcommand which queries the audit log.
+func history(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	var kickerConfPath string
+	fs.StringVar(&kickerConfPath, "config", "", "absolute path to the kicker config file used to locate the audit log (optional)")
+	var logPath string
+	fs.StringVar(&logPath, "log", "", "path to the audit log, overrides the auditLog of the config file (optional)")
+	var q audit.Query
+	fs.StringVar(&q.Cluster, "cluster", "", "only show kicks in this cluster (optional)")
+	fs.StringVar(&q.Criteria, "criteria", "", "only show kicks made by this criteria (optional)")
+	fs.StringVar(&q.Namespace, "namespace", "", "only show kicks in this namespace (optional)")
+	fs.StringVar(&q.Owner, "owner", "", "only show kicks of pods controlled by this owner, in the form Kind/Name (optional)")
+	var outcome string
+	fs.StringVar(&outcome, "outcome", "", "only show kicks with this outcome: kicked, quarantined, failed, dryrun or refused (optional)")
+	var since, until string
+	fs.StringVar(&since, "since", "", "only show kicks at or after this RFC3339 time or duration ago, e.g. 12h (optional)")
+	fs.StringVar(&until, "until", "", "only show kicks before this RFC3339 time or duration ago, e.g. 1h (optional)")
+	var output string
+	fs.StringVar(&output, "output", "table", "output format: table or json (optional)")
+	var summary bool
+	fs.BoolVar(&summary, "summary", false, "print kicks per criteria per day and the failure rate instead of each kick (optional)")
+	fs.Parse(args)
+
+	if logPath == "" {
+		config, err := conf.LoadConf(kickerConfPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.AuditLog == "" {
+			log.Fatalln("no audit log provided and config does not define auditLog")
+		}
+
+		logPath = config.AuditLog
+	}
+
+	q.Outcome = audit.Outcome(outcome)
+
+	var err error
+	if q.Since, err = parseTime(since); err != nil {
+		log.Fatalln(err)
+	}
+
+	if q.Until, err = parseTime(until); err != nil {
+		log.Fatalln(err)
+	}
+
+	records, err := audit.Read(logPath, q)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	switch output {
+	case "json":
+		err = printJSON(records, summary)
+	case "table":
+		err = printTable(records, summary)
+	default:
+		err = fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// parseTime parses either an RFC3339 timestamp or a duration that is subtracted from now. An empty string returns the
+// zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is neither an RFC3339 time nor a duration", s)
+	}
+
+	return t, nil
+}
+
+func printJSON(records []audit.Record, summary bool) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if summary {
+		return enc.Encode(struct {
+			KicksPerDay []audit.DailyCount `json:"kicksPerDay"`
+			FailureRate float64            `json:"failureRate"`
+		}{
+			KicksPerDay: audit.KicksPerDay(records),
+			FailureRate: audit.FailureRate(records),
+		})
+	}
+
+	if records == nil {
+		records = []audit.Record{}
+	}
+
+	return enc.Encode(records)
+}
+
+func printTable(records []audit.Record, summary bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if summary {
+		fmt.Fprintln(w, "DAY\tCRITERIA\tKICKS\tFAILURES\tREFUSED")
+		for _, dc := range audit.KicksPerDay(records) {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", dc.Day, dc.Criteria, dc.Kicks, dc.Failures, dc.Refused)
+		}
+
+		fmt.Fprintf(w, "\nfailure rate: %.1f%%\n", audit.FailureRate(records)*100)
+		return w.Flush()
+	}
+
+	fmt.Fprintln(w, "TIME\tCLUSTER\tCRITERIA\tNAMESPACE\tPOD\tOWNER\tOUTCOME\tERROR")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Time.Format(time.RFC3339), r.Cluster, r.Criteria, r.Namespace, r.Pod, r.Owner, r.Outcome, r.Error)
+	}
+
+	return w.Flush()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"os"
 
 	"github.com/curlymon/kicker/pkg/engine"
 	_ "github.com/curlymon/kicker/pkg/strategy/all" // this loads all strategies
@@ -9,6 +10,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		history(os.Args[2:])
+		return
+	}
+
 	var kickerConfPath string
 	flag.StringVar(&kickerConfPath, "config", "", "absolute path to the kicker config file (optional)")
 	var dryRun bool
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/killswitch"
+	"github.com/curlymon/kicker/pkg/metrics"
+	"github.com/curlymon/kicker/pkg/strategy"
+)
+
+// Controller is the view of the engine that the API server operates on.
+type Controller interface {
+	// Ready reports whether the engine has completed its first successful pod list. When more than one cluster is
+	// managed, it reports whether any cluster has; the readiness of each is in Status.Clusters.
+	Ready() bool
+
+	// Status returns the current status of the engine
+	Status() Status
+
+	// Pause stops the named criteria from kicking until it is resumed
+	Pause(criteria string) error
+
+	// Resume allows the named criteria to kick again
+	Resume(criteria string) error
+
+	// Trigger requests an immediate evaluation of all criteria
+	Trigger()
+}
+
+// Status is the body returned by /status
+type Status struct {
+	Cluster    string           `json:"cluster,omitempty"`
+	Ready      bool             `json:"ready"`
+	DryRun     bool             `json:"dryRun"`
+	KillSwitch killswitch.State `json:"killSwitch"`
+	Criteria   []CriteriaStatus `json:"criteria"`
+
+	// Clusters holds the status of each cluster when more than one is managed
+	Clusters []Status `json:"clusters,omitempty"`
+}
+
+// CriteriaStatus is the status of a single criteria
+type CriteriaStatus struct {
+	strategy.Status
+	Name     string        `json:"name"`
+	Strategy conf.Strategy `json:"strategy"`
+	Paused   bool          `json:"paused"`
+
+	// Overlapping is the number of pods matched by the criteria that were also matched by another criteria at the last
+	// evaluation
+	Overlapping int `json:"overlapping"`
+}
+
+// Serve starts the API server for the passed Controller in the background. Errors serving are logged.
+func Serve(c conf.API, ctrl Controller) {
+	go func() {
+		log.Printf("serving api on %s", c.Addr)
+		if err := http.ListenAndServe(c.Addr, Handler(c, ctrl)); err != nil {
+			log.Printf("error serving api: %s", err)
+		}
+	}()
+}
+
+// Handler builds the http.Handler serving the API for the passed Controller.
+func Handler(c conf.API, ctrl Controller) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !ctrl.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.Handle("/metrics", metrics.Handler())
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ctrl.Status()); err != nil {
+			log.Printf("error writing status: %s", err)
+		}
+	})
+
+	mux.Handle("/pause", control(c.Token, func(r *http.Request) error {
+		return ctrl.Pause(r.URL.Query().Get("criteria"))
+	}))
+
+	mux.Handle("/resume", control(c.Token, func(r *http.Request) error {
+		return ctrl.Resume(r.URL.Query().Get("criteria"))
+	}))
+
+	mux.Handle("/evaluate", control(c.Token, func(r *http.Request) error {
+		ctrl.Trigger()
+		return nil
+	}))
+
+	return mux
+}
+
+// control wraps an action so that it is only reachable by POST with the configured bearer token.
+func control(token string, action func(*http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if token == "" {
+			http.Error(w, "control endpoints are disabled", http.StatusForbidden)
+			return
+		}
+
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := action(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("api: %s %s", r.URL.Path, r.URL.RawQuery)
+		fmt.Fprintln(w, "ok")
+	})
+}
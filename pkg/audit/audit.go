@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+// Outcome describes the result of a single kick attempt
+type Outcome string
+
+const (
+	// OutcomeKicked is recorded when a pod was successfully kicked
+	OutcomeKicked Outcome = "kicked"
+	// OutcomeFailed is recorded when kicking a pod returned an error
+	OutcomeFailed Outcome = "failed"
+	// OutcomeDryRun is recorded when a pod would have been kicked but dry run mode was enabled
+	OutcomeDryRun Outcome = "dryrun"
+	// OutcomeRefused is recorded when a pod selected by a strategy was not kicked because a safeguard refused it
+	OutcomeRefused Outcome = "refused"
+	// OutcomeQuarantined is recorded when a pod was successfully quarantined rather than deleted
+	OutcomeQuarantined Outcome = "quarantined"
+)
+
+// Record is a single entry in the audit log. Records are stored one JSON object per line.
+type Record struct {
+	// Time is when the kick was attempted
+	Time time.Time `json:"time"`
+
+	// Cluster is the name of the conf.Cluster the pod runs in, empty when a single cluster is managed
+	Cluster string `json:"cluster,omitempty"`
+
+	// Criteria is the name of the conf.Criteria whose strategy selected the pod
+	Criteria string `json:"criteria"`
+
+	// Namespace is the namespace of the kicked pod
+	Namespace string `json:"namespace"`
+
+	// Pod is the name of the kicked pod
+	Pod string `json:"pod"`
+
+	// Owner is the controlling owner of the pod in the form Kind/Name, empty if the pod has no controller
+	Owner string `json:"owner,omitempty"`
+
+	// Outcome is the result of the kick attempt
+	Outcome Outcome `json:"outcome"`
+
+	// Error holds the error message when Outcome is OutcomeFailed, or the reason when Outcome is OutcomeRefused
+	Error string `json:"error,omitempty"`
+}
+
+// NewRecord builds a Record for the passed pod and criteria name stamped with the current time.
+func NewRecord(criteria string, pod v1.Pod, outcome Outcome, err error) Record {
+	r := Record{
+		Time:      time.Now().UTC(),
+		Criteria:  criteria,
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Owner:     Owner(pod),
+		Outcome:   outcome,
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// Owner returns the controlling owner reference of the passed pod in the form Kind/Name. An empty string is returned if
+// the pod is not controlled by anything.
+func Owner(pod v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return ref.Kind + "/" + ref.Name
+		}
+	}
+
+	return ""
+}
+
+// Log is an append only audit log of kick attempts backed by a file. It is safe for concurrent use.
+type Log struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// Open opens, creating if needed, the audit log at the passed path for appending.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log '%s': %s", path, err)
+	}
+
+	return &Log{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}, nil
+}
+
+// Write appends the passed Record to the audit log. A nil Log discards all records.
+func (l *Log) Write(r Record) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(r); err != nil {
+		return fmt.Errorf("error writing audit record: %s", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying file of the audit log.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
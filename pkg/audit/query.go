@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Query defines a set of constraints used to select Records from the audit log. Zero valued fields are ignored.
+type Query struct {
+	// Cluster matches Records with an equivalent Cluster name
+	Cluster string
+
+	// Criteria matches Records with an equivalent Criteria name
+	Criteria string
+
+	// Namespace matches Records with an equivalent Namespace
+	Namespace string
+
+	// Owner matches Records with an equivalent Owner, in the form Kind/Name
+	Owner string
+
+	// Outcome matches Records with an equivalent Outcome
+	Outcome Outcome
+
+	// Since matches Records at or after this time
+	Since time.Time
+
+	// Until matches Records before this time
+	Until time.Time
+}
+
+// Match reports whether the passed Record satisfies all constraints of the Query.
+func (q Query) Match(r Record) bool {
+	if q.Cluster != "" && r.Cluster != q.Cluster {
+		return false
+	}
+
+	if q.Criteria != "" && r.Criteria != q.Criteria {
+		return false
+	}
+
+	if q.Namespace != "" && r.Namespace != q.Namespace {
+		return false
+	}
+
+	if q.Owner != "" && r.Owner != q.Owner {
+		return false
+	}
+
+	if q.Outcome != "" && r.Outcome != q.Outcome {
+		return false
+	}
+
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && !r.Time.Before(q.Until) {
+		return false
+	}
+
+	return true
+}
+
+// Read reads the audit log at the passed path and returns all Records matching the passed Query in the order they were
+// written.
+func Read(path string, q Query) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log '%s': %s", path, err)
+	}
+	defer f.Close()
+
+	var out []Record
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("error parsing audit log '%s' line %d: %s", path, line, err)
+		}
+
+		if q.Match(r) {
+			out = append(out, r)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit log '%s': %s", path, err)
+	}
+
+	return out, nil
+}
+
+// DailyCount is the number of kick attempts made by a single criteria on a single day
+type DailyCount struct {
+	// Day is the UTC day in the form YYYY-MM-DD
+	Day string `json:"day"`
+
+	// Criteria is the name of the criteria
+	Criteria string `json:"criteria"`
+
+	// Kicks is the number of successful kicks
+	Kicks int `json:"kicks"`
+
+	// Failures is the number of failed kicks
+	Failures int `json:"failures"`
+
+	// Refused is the number of kicks refused by a safeguard
+	Refused int `json:"refused"`
+}
+
+// KicksPerDay aggregates the passed Records into counts per criteria per UTC day, ordered by day then criteria. Dry run
+// and quarantined Records are counted as kicks.
+func KicksPerDay(records []Record) []DailyCount {
+	type key struct{ day, criteria string }
+	counts := map[key]*DailyCount{}
+	for _, r := range records {
+		k := key{r.Time.UTC().Format("2006-01-02"), r.Criteria}
+		dc, ok := counts[k]
+		if !ok {
+			dc = &DailyCount{Day: k.day, Criteria: k.criteria}
+			counts[k] = dc
+		}
+
+		switch r.Outcome {
+		case OutcomeFailed:
+			dc.Failures++
+		case OutcomeRefused:
+			dc.Refused++
+		default:
+			dc.Kicks++
+		}
+	}
+
+	out := make([]DailyCount, 0, len(counts))
+	for _, dc := range counts {
+		out = append(out, *dc)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].Criteria < out[j].Criteria
+	})
+
+	return out
+}
+
+// FailureRate returns the fraction of attempted kicks in the passed Records that have OutcomeFailed. Refused Records
+// were never attempted and are ignored. Zero is returned when no kicks were attempted.
+func FailureRate(records []Record) float64 {
+	var attempted, failed int
+	for _, r := range records {
+		switch r.Outcome {
+		case OutcomeRefused:
+			continue
+		case OutcomeFailed:
+			failed++
+		}
+
+		attempted++
+	}
+
+	if attempted <= 0 {
+		return 0
+	}
+
+	return float64(failed) / float64(attempted)
+}
@@ -0,0 +1,109 @@
+package budget
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// Budget enforces a conf.Budget across all kicks. It is safe for concurrent use.
+type Budget struct {
+	c      conf.Budget
+	window time.Duration
+
+	mu         sync.Mutex
+	global     bucket
+	namespaces map[string]*bucket
+	nodes      map[string]*bucket
+}
+
+// New builds a Budget for the passed conf.Budget. A nil Budget allows every kick.
+func New(c conf.Budget) *Budget {
+	if !c.Enabled() {
+		return nil
+	}
+
+	return &Budget{
+		c:          c,
+		window:     time.Duration(c.Window) * time.Second,
+		namespaces: map[string]*bucket{},
+		nodes:      map[string]*bucket{},
+	}
+}
+
+// Allow reports whether the passed pod may be kicked at now, consuming a token from every applicable cap if so. When the
+// kick is not allowed a reason naming the exhausted cap is returned and no tokens are consumed.
+func (b *Budget) Allow(pod v1.Pod, now time.Time) (bool, string) {
+	if b == nil {
+		return true, ""
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	type check struct {
+		bkt      *bucket
+		capacity int64
+		reason   string
+	}
+
+	var checks []check
+	if b.c.Kicks > 0 {
+		checks = append(checks, check{&b.global, b.c.Kicks, "cluster-wide"})
+	}
+
+	if b.c.PerNamespace > 0 {
+		checks = append(checks, check{keyed(b.namespaces, pod.Namespace), b.c.PerNamespace, fmt.Sprintf("namespace '%s'", pod.Namespace)})
+	}
+
+	if b.c.PerNode > 0 && pod.Spec.NodeName != "" {
+		checks = append(checks, check{keyed(b.nodes, pod.Spec.NodeName), b.c.PerNode, fmt.Sprintf("node '%s'", pod.Spec.NodeName)})
+	}
+
+	for _, c := range checks {
+		if c.bkt.available(now, c.capacity, b.window) < 1 {
+			return false, fmt.Sprintf("%s kick budget of %d per %s exhausted", c.reason, c.capacity, b.window)
+		}
+	}
+
+	for _, c := range checks {
+		c.bkt.tokens--
+	}
+
+	return true, ""
+}
+
+func keyed(buckets map[string]*bucket, key string) *bucket {
+	bkt, ok := buckets[key]
+	if !ok {
+		bkt = &bucket{}
+		buckets[key] = bkt
+	}
+
+	return bkt
+}
+
+// bucket is a token bucket that refills capacity tokens evenly over a window.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// available refills the bucket up to now and returns the number of tokens available.
+func (b *bucket) available(now time.Time, capacity int64, window time.Duration) float64 {
+	if b.last.IsZero() {
+		b.tokens = float64(capacity)
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(capacity) * float64(elapsed) / float64(window)
+	}
+
+	if b.tokens > float64(capacity) {
+		b.tokens = float64(capacity)
+	}
+
+	b.last = now
+	return b.tokens
+}
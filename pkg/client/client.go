@@ -2,49 +2,74 @@ package client
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"time"
 
 	"github.com/curlymon/kicker/pkg/conf"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // the following line to loads the gcp plugin (only required to authenticate against GKE clusters).
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 // New instantiates a new kubernetes.ClientSet from the config file or the environment.
 func New(c conf.Conf) (*kubernetes.Clientset, error) {
-	if c.KubeConf != "" {
-		config, err := clientcmd.BuildConfigFromFlags("", c.KubeConf)
-		if err != nil {
-			return nil, fmt.Errorf("error creating client from KubeConf: %s", err)
-		}
-
-		return kubernetes.NewForConfig(config)
+	config, err := RestConfig(c)
+	if err != nil {
+		return nil, err
 	}
 
-	if config, err := rest.InClusterConfig(); err == nil {
-		return kubernetes.NewForConfig(config)
-	} else if err != rest.ErrNotInCluster {
-		return nil, fmt.Errorf("error creating client from InClusterConfig: %s", err)
+	return kubernetes.NewForConfig(config)
+}
+
+// NewMetrics instantiates a new clientset for the metrics.k8s.io API from the config file or the environment.
+func NewMetrics(c conf.Conf) (*metricsclient.Clientset, error) {
+	config, err := RestConfig(c)
+	if err != nil {
+		return nil, err
 	}
 
-	if home := homeDir(); home != "" {
-		path := filepath.Join(home, ".kube", "config")
-		config, err := clientcmd.BuildConfigFromFlags("", path)
-		if err != nil {
-			return nil, fmt.Errorf("error creating client from '%s': %s", path, err)
+	return metricsclient.NewForConfig(config)
+}
+
+// RestConfig resolves the rest.Config used to connect to the cluster the way kubectl does: conf.Conf.KubeConf if set,
+// otherwise the files listed in KUBECONFIG merged together, otherwise ~/.kube/config, using conf.Conf.Context or the
+// current context. If none of those exist and no Context is set the in-cluster config is used. conf.Conf.Client is then
+// applied on top.
+func RestConfig(c conf.Conf) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = c.KubeConf
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		rules,
+		&clientcmd.ConfigOverrides{CurrentContext: c.Context},
+	).ClientConfig()
+	if err != nil {
+		if c.KubeConf != "" {
+			return nil, fmt.Errorf("error creating client from KubeConf: %s", err)
 		}
 
-		return kubernetes.NewForConfig(config)
+		return nil, fmt.Errorf("No KubeConf provided and unable to resolve config from KUBECONFIG, the invoking user's home directory or InClusterConfig: %s", err)
 	}
 
-	return nil, fmt.Errorf("No KubeConf provided and unable to resolve config from InClusterConfig or invoking user's home directory")
+	tune(config, c.Client)
+	return config, nil
 }
 
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
+// tune applies the passed conf.Client to config. Impersonation set in the kubeconfig is only replaced when
+// conf.Client.As is set.
+func tune(config *rest.Config, c conf.Client) {
+	if c.As != "" {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: c.As,
+			Groups:   c.AsGroups,
+		}
+	}
+
+	if c.Timeout > 0 {
+		config.Timeout = time.Duration(c.Timeout) * time.Second
 	}
-	return os.Getenv("USERPROFILE") // windows
+
+	config.QPS = c.QPS
+	config.Burst = c.Burst
 }
@@ -0,0 +1,45 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultAction is the default Action if one is not provided in a Criteria Object
+	DefaultAction = ActionDelete
+)
+
+// Action defines what is done to a pod once it is kicked
+type Action string
+
+const (
+	// ActionDelete deletes the pod so that its controller replaces it.
+	ActionDelete = "delete"
+	// ActionQuarantine keeps the pod running but relabels it so that it no longer matches its controller's selector or
+	// any Service, taking it out of rotation while its controller creates a replacement. The pod is annotated with why
+	// it was quarantined and, if Quarantine.TTL is set, deleted once the TTL expires.
+	ActionQuarantine = "quarantine"
+)
+
+// Quarantine configures ActionQuarantine.
+type Quarantine struct {
+	// TTL is the duration in seconds a quarantined pod is kept before it is deleted. If not provided or <= 0 quarantined
+	// pods are kept until removed by hand.
+	TTL int64 `yaml:"ttl"`
+}
+
+func (c *Criteria) validateAction() error {
+	switch c.Action {
+	case "":
+		c.Action = DefaultAction
+	case ActionDelete, ActionQuarantine:
+	default:
+		return fmt.Errorf("Action '%s' is unknown", c.Action)
+	}
+
+	if c.Quarantine.TTL < 0 {
+		c.Quarantine.TTL = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package conf
+
+// API defines the HTTP status and control server.
+type API struct {
+	// Addr is the address the server listens on, e.g. ":8080". If left empty the server is disabled.
+	Addr string `yaml:"addr"`
+
+	// Token is the bearer token required by the control endpoints. If left empty the control endpoints are disabled and
+	// only health and status are served.
+	Token string `yaml:"token"`
+}
+
+// Enabled reports whether the API server is configured.
+func (a API) Enabled() bool {
+	return a.Addr != ""
+}
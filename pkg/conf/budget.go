@@ -0,0 +1,43 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultBudgetWindow is the default Window in seconds if one is not provided in a Budget Object
+	DefaultBudgetWindow = 600
+)
+
+// Budget caps the total number of kicks across all criteria. Each cap is a token bucket holding at most its limit of
+// kicks that refills completely over Window. A cap of 0 disables it.
+type Budget struct {
+	// Kicks is the maximum number of kicks cluster-wide per Window.
+	Kicks int64 `yaml:"kicks"`
+
+	// PerNamespace is the maximum number of kicks within any single namespace per Window.
+	PerNamespace int64 `yaml:"perNamespace"`
+
+	// PerNode is the maximum number of kicks of pods scheduled on any single node per Window.
+	PerNode int64 `yaml:"perNode"`
+
+	// Window is the period in seconds over which the caps apply. Defaults to DefaultBudgetWindow if not provided or <= 0.
+	Window int64 `yaml:"window"`
+}
+
+// Enabled reports whether any cap of the Budget is set.
+func (b Budget) Enabled() bool {
+	return b.Kicks > 0 || b.PerNamespace > 0 || b.PerNode > 0
+}
+
+func (b *Budget) validate() error {
+	if b.Kicks < 0 || b.PerNamespace < 0 || b.PerNode < 0 {
+		return fmt.Errorf("Budget caps must not be negative")
+	}
+
+	if b.Window <= 0 {
+		b.Window = DefaultBudgetWindow
+	}
+
+	return nil
+}
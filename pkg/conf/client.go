@@ -0,0 +1,54 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultClientQPS is the default QPS if one is not provided in a Client Object
+	DefaultClientQPS = 5
+
+	// DefaultClientBurst is the default Burst if one is not provided in a Client Object
+	DefaultClientBurst = 10
+)
+
+// Client tunes the connection to the Kubernetes API. It applies on top of whichever kubeconfig or in-cluster config is
+// resolved, like the equivalent kubectl flags.
+type Client struct {
+	// As is the user to impersonate, as with kubectl --as. If left empty no user is impersonated.
+	As string `yaml:"as"`
+
+	// AsGroups are the groups to impersonate, as with kubectl --as-group.
+	AsGroups []string `yaml:"asGroups"`
+
+	// Timeout is the timeout in seconds of a single request, as with kubectl --request-timeout. It also bounds watches
+	// and log reads, which are re-established as needed. If not provided or 0 requests do not time out.
+	Timeout int64 `yaml:"timeout"`
+
+	// QPS is the maximum sustained number of requests per second. Defaults to DefaultClientQPS if not provided or <= 0.
+	QPS float32 `yaml:"qps"`
+
+	// Burst is the maximum number of requests allowed above QPS for short periods. Defaults to DefaultClientBurst if not
+	// provided or <= 0.
+	Burst int `yaml:"burst"`
+}
+
+func (c *Client) validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("Client Timeout must not be negative")
+	}
+
+	if len(c.AsGroups) > 0 && c.As == "" {
+		return fmt.Errorf("Client AsGroups requires As to be set")
+	}
+
+	if c.QPS <= 0 {
+		c.QPS = DefaultClientQPS
+	}
+
+	if c.Burst <= 0 {
+		c.Burst = DefaultClientBurst
+	}
+
+	return nil
+}
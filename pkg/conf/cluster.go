@@ -0,0 +1,83 @@
+package conf
+
+import (
+	"fmt"
+)
+
+// Cluster is one of the clusters managed by a single kicker. Every cluster is evaluated in its own loop with its own
+// state, so an unreachable cluster does not affect the others.
+type Cluster struct {
+	// Name identifies the cluster in criteria, the audit log, notifications and the status API.
+	// This is a required field
+	Name string `yaml:"name"`
+
+	// KubeConf is the kubeconfig file of the cluster. If left empty Conf.KubeConf is used.
+	KubeConf string `yaml:"kubeConf"`
+
+	// Context is the kubeconfig context of the cluster. If left empty Conf.Context is used.
+	Context string `yaml:"context"`
+}
+
+// ForCluster returns the Conf used to manage the passed Cluster: the connection settings are those of the Cluster and
+// the Criteria are limited to those scoped to it.
+func (c Conf) ForCluster(cl Cluster) Conf {
+	out := c
+	if cl.KubeConf != "" {
+		out.KubeConf = cl.KubeConf
+	}
+
+	if cl.Context != "" {
+		out.Context = cl.Context
+	}
+
+	out.Clusters = nil
+	out.Criteria = nil
+	for _, cr := range c.Criteria {
+		if cr.InCluster(cl.Name) {
+			out.Criteria = append(out.Criteria, cr)
+		}
+	}
+
+	return out
+}
+
+// InCluster reports whether the Criteria applies to the named cluster. Criteria without Clusters apply to every
+// cluster.
+func (c Criteria) InCluster(name string) bool {
+	if len(c.Clusters) <= 0 {
+		return true
+	}
+
+	for _, cl := range c.Clusters {
+		if cl == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Conf) validateClusters() error {
+	names := map[string]bool{}
+	for _, cl := range c.Clusters {
+		if cl.Name == "" {
+			return fmt.Errorf("Cluster must have a Name")
+		}
+
+		if names[cl.Name] {
+			return fmt.Errorf("Cluster %s is defined more than once", cl.Name)
+		}
+
+		names[cl.Name] = true
+	}
+
+	for _, cr := range c.Criteria {
+		for _, name := range cr.Clusters {
+			if !names[name] {
+				return fmt.Errorf("Criteria %s references unknown Cluster %s", cr.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForCluster(t *testing.T) {
+	c := Conf{
+		KubeConf: "/etc/kicker/kubeconfig",
+		Context:  "default",
+		Clusters: []Cluster{{Name: "east", KubeConf: "/etc/kicker/east"}, {Name: "west", Context: "west"}, {Name: "north"}},
+		Criteria: []Criteria{
+			{Name: "everywhere"},
+			{Name: "east-only", Clusters: []string{"east"}},
+			{Name: "both", Clusters: []string{"west", "east"}},
+		},
+	}
+
+	tests := []struct {
+		cluster  Cluster
+		kubeConf string
+		context  string
+		criteria []string
+	}{
+		{
+			cluster:  c.Clusters[0],
+			kubeConf: "/etc/kicker/east",
+			context:  "default",
+			criteria: []string{"everywhere", "east-only", "both"},
+		},
+		{
+			cluster:  c.Clusters[1],
+			kubeConf: "/etc/kicker/kubeconfig",
+			context:  "west",
+			criteria: []string{"everywhere", "both"},
+		},
+		{
+			cluster:  c.Clusters[2],
+			kubeConf: "/etc/kicker/kubeconfig",
+			context:  "default",
+			criteria: []string{"everywhere"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cluster.Name, func(t *testing.T) {
+			got := c.ForCluster(tt.cluster)
+			if got.KubeConf != tt.kubeConf || got.Context != tt.context {
+				t.Errorf("ForCluster() connects with kubeConf '%s' context '%s', want '%s' '%s'", got.KubeConf, got.Context, tt.kubeConf, tt.context)
+			}
+
+			if got.Clusters != nil {
+				t.Errorf("ForCluster() kept Clusters %v, want none", got.Clusters)
+			}
+
+			names := []string{}
+			for _, cr := range got.Criteria {
+				names = append(names, cr.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.criteria) {
+				t.Errorf("ForCluster() criteria = %v, want %v", names, tt.criteria)
+			}
+		})
+	}
+
+	if len(c.Criteria) != 3 || len(c.Clusters) != 3 {
+		t.Errorf("ForCluster() modified the Conf it was called on")
+	}
+}
+
+func TestValidateClusters(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters []Cluster
+		criteria []Criteria
+		err      string
+	}{
+		{
+			name: "no clusters",
+		},
+		{
+			name:     "criteria scoped to known clusters",
+			clusters: []Cluster{{Name: "east"}, {Name: "west"}},
+			criteria: []Criteria{{Name: "web", Clusters: []string{"east"}}, {Name: "api"}},
+		},
+		{
+			name:     "cluster without a name",
+			clusters: []Cluster{{Name: "east"}, {KubeConf: "/etc/kicker/west"}},
+			err:      "Cluster must have a Name",
+		},
+		{
+			name:     "duplicate cluster",
+			clusters: []Cluster{{Name: "east"}, {Name: "east"}},
+			err:      "Cluster east is defined more than once",
+		},
+		{
+			name:     "unknown cluster",
+			clusters: []Cluster{{Name: "east"}},
+			criteria: []Criteria{{Name: "web", Clusters: []string{"west"}}},
+			err:      "Criteria web references unknown Cluster west",
+		},
+		{
+			name:     "criteria scoped without clusters",
+			criteria: []Criteria{{Name: "web", Clusters: []string{"east"}}},
+			err:      "Criteria web references unknown Cluster east",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Conf{Clusters: tt.clusters, Criteria: tt.criteria}
+			err := c.validateClusters()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("validateClusters() error = %s", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("validateClusters() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
@@ -2,23 +2,78 @@ package conf
 
 import (
 	"fmt"
+
+	"github.com/curlymon/kicker/pkg/expr"
 )
 
 const (
 	// DefaultCheckInterval is the default CheckInterval if one is not provided in a Conf Object
 	DefaultCheckInterval = 60
+
+	// DefaultStuckAfter is the default StuckAfter if one is not provided in a Conf Object
+	DefaultStuckAfter = 3
+
+	// DefaultOverlap is the default Overlap if one is not provided in a Conf Object
+	DefaultOverlap = OverlapFirst
 )
 
 // Conf is the basic configuration structure to be used by this program. It defines kubernetes config locations as well
 // as pod kicking criteria.
 type Conf struct {
 	// KubeConf defines a path to a configuration file to use when connecting to the Kubernetes Cluster we are managing
-	// pod kicking for. If not provided it will attempt to use one setup within the home directory of the invokeing user.
+	// pod kicking for. If not provided the files listed in KUBECONFIG are merged, falling back to the one setup within
+	// the home directory of the invokeing user and then to the in-cluster config, as kubectl does.
 	KubeConf string `yaml:"kubeConf"`
 
+	// Context is the kubeconfig context to use. If left empty the current context of the kubeconfig is used.
+	Context string `yaml:"context"`
+
+	// Client tunes the connection to the Kubernetes API: impersonation, request timeout and rate limits.
+	Client Client `yaml:"client"`
+
+	// Clusters lists the clusters managed by this kicker. If left empty the single cluster defined by KubeConf and
+	// Context is managed. The remaining settings, such as the Budget and the KillSwitch, apply to each cluster
+	// separately, while the AuditLog and Webhooks are shared.
+	Clusters []Cluster `yaml:"clusters"`
+
 	// CheckInterval defines the interval in seconds between kicker evaluations
 	CheckInterval int64 `yaml:"checkInterval"`
 
+	// API defines the HTTP server exposing health, status and control endpoints.
+	API API `yaml:"api"`
+
+	// Metrics defines an HTTP server exposing only the Prometheus metrics, independently of the API.
+	Metrics Metrics `yaml:"metrics"`
+
+	// AuditLog defines a path to a file that every kick attempt is appended to as a JSON record. If not provided kick
+	// attempts are only written to the process log.
+	AuditLog string `yaml:"auditLog"`
+
+	// StuckAfter is the number of consecutive evaluations in which every kick of a criteria failed before the criteria
+	// is reported as stuck. Defaults to DefaultStuckAfter if not provided or <= 0.
+	StuckAfter int64 `yaml:"stuckAfter"`
+
+	// OptIn restricts kicking to pods that are, or whose namespace is, annotated kicker.io/enabled: "true". Pods and
+	// their owners can always opt out with kicker.io/skip: "true" or kicker.io/pause-until: <RFC3339 timestamp>.
+	OptIn bool `yaml:"optIn"`
+
+	// KillSwitch defines a ConfigMap that can pause kicking or force dry run mode during incidents.
+	KillSwitch KillSwitch `yaml:"killSwitch"`
+
+	// Prometheus defines the Prometheus server queried by prometheus triggers.
+	Prometheus Prometheus `yaml:"prometheus"`
+
+	// Budget caps the total number of kicks across all criteria. When the budget is exhausted pods are kicked in
+	// criteria order until it runs out and the remainder are refused.
+	Budget Budget `yaml:"budget"`
+
+	// Overlap is the resolution applied when a pod is selected by more than one criteria: first, priority or refuse.
+	// Defaults to DefaultOverlap if left empty.
+	Overlap Overlap `yaml:"overlap"`
+
+	// Webhooks is the set of outbound webhooks notified when kicker acts.
+	Webhooks []Webhook `yaml:"webhooks"`
+
 	// Criteria is the set of targetting strategies for this programm to use. At least one valid criteria must be provided.
 	Criteria []Criteria `yaml:"criteria"`
 }
@@ -28,6 +83,34 @@ func (c *Conf) validate() error {
 		c.CheckInterval = DefaultCheckInterval
 	}
 
+	if c.StuckAfter <= 0 {
+		c.StuckAfter = DefaultStuckAfter
+	}
+
+	switch c.Overlap {
+	case "":
+		c.Overlap = DefaultOverlap
+	case OverlapFirst, OverlapPriority, OverlapRefuse:
+	default:
+		return fmt.Errorf("Overlap '%s' is unknown", c.Overlap)
+	}
+
+	if c.Metrics.Enabled() && c.Metrics.Addr == c.API.Addr {
+		return fmt.Errorf("Metrics addr '%s' is already used by the API, which serves metrics on /metrics", c.Metrics.Addr)
+	}
+
+	if err := c.Client.validate(); err != nil {
+		return err
+	}
+
+	if err := c.KillSwitch.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Budget.validate(); err != nil {
+		return err
+	}
+
 	if len(c.Criteria) <= 0 {
 		return fmt.Errorf("Must provide at least one Criteria in conf")
 	}
@@ -36,6 +119,22 @@ func (c *Conf) validate() error {
 		if err := c.Criteria[i].validate(); err != nil {
 			return err
 		}
+
+		if c.Criteria[i].Triggers.Prometheus != nil && c.Prometheus.URL == "" {
+			return fmt.Errorf("Criteria %s has a prometheus trigger but no Prometheus URL is configured", c.Criteria[i].Name)
+		}
+	}
+
+	if err := c.Prometheus.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateClusters(); err != nil {
+		return err
+	}
+
+	if err := c.validateWebhooks(); err != nil {
+		return err
 	}
 
 	return nil
@@ -72,7 +171,7 @@ type Criteria struct {
 	Namespace string `yaml:"namespace"`
 
 	// MaxAge is the maximum age in seconds that a pod should live for to be eligible for kicking.
-	// Must be greater then MinAge. Defaults to DefaultMaxAge if not provided or <= 0.
+	// Must be greater then MinAge. Defaults to DefaultMaxAge if not provided or <= 0. Ignored by cleanup Kinds.
 	MaxAge int64 `yaml:"maxAge"`
 
 	// MinAge is the minimum age in seconds that a pod should be alive for to before being considered eligible for
@@ -80,17 +179,65 @@ type Criteria struct {
 	// Must be less then MaxAge. Defaults to DefaultMinAge if not provided or <= 0.
 	MinAge int64 `yaml:"minAge"`
 
-	// Strategy is the strategy used to manage which pod is kicked if needed. Defaults to DefaultStrategy
+	// Strategy is the strategy used to manage which pod is kicked if needed. Defaults to DefaultStrategy, or to
+	// StrategyCleanup for cleanup Kinds
 	Strategy Strategy `yaml:"strategy"`
 
-	// Limit is the maximum count of pods that can be kicked per evaluation period. Defaults to DefaultLimit
-	Limit int64 `yaml:"limit"`
+	// Expression is an optional CEL expression over the pod that must evaluate to true for the pod to be targeted, e.g.
+	// pod.status.containerStatuses.exists(c, c.restartCount > 3). The pod is exposed as its JSON representation.
+	Expression string `yaml:"expression"`
+
+	// Kind is the state of the pods targeted: running, terminating, failed or succeeded. Defaults to DefaultKind if left
+	// empty. For cleanup kinds MinAge is measured from when the pod entered the state and MaxAge is ignored.
+	Kind Kind `yaml:"kind"`
+
+	// Limit is the maximum count of pods that can be kicked per evaluation period, either absolute or a percentage of
+	// the fleet. Defaults to DefaultLimit
+	Limit Limit `yaml:"limit"`
+
+	// LimitPolicy defines how a percentage Limit is resolved to a count.
+	LimitPolicy LimitPolicy `yaml:"limitPolicy"`
 
 	// GracePeriod is the grace period in seconds that a kicked pod will have when shutting down
 	GracePeriod int64 `yaml:"gracePeriod"`
 
 	// DefaultCoolDown is the cool down in seconds that a strategy will wait before being elidgable to kick a pod
 	CoolDown int64 `yaml:"coolDown"`
+
+	// Clusters are the names of the Conf.Clusters the criteria applies to. If left empty it applies to every cluster.
+	Clusters []string `yaml:"clusters"`
+
+	// Priority orders criteria when their kicks are merged; higher priorities are kicked first and, with the priority
+	// Overlap, win pods selected by more than one criteria. Criteria of equal priority keep their config order.
+	Priority int64 `yaml:"priority"`
+
+	// Triggers restrict the criteria to pods showing a symptom.
+	Triggers Triggers `yaml:"triggers"`
+
+	// Random configures the random strategy. It is ignored by other strategies.
+	Random Random `yaml:"random"`
+
+	// Topology limits the pods kicked per node and per topology domain. It applies on top of any strategy.
+	Topology Topology `yaml:"topology"`
+
+	// Diagnostics captures the state of pods before they are kicked.
+	Diagnostics Diagnostics `yaml:"diagnostics"`
+
+	// Action is what is done to kicked pods: delete or quarantine. Defaults to DefaultAction if left empty.
+	Action Action `yaml:"action"`
+
+	// Quarantine configures the quarantine Action. It is ignored by other actions.
+	Quarantine Quarantine `yaml:"quarantine"`
+
+	// PreKick is an optional Hook executed in the pod before it is kicked.
+	PreKick *Hook `yaml:"preKick"`
+
+	// Pipeline is the list of stages composing the pipeline strategy. It is required by, and only valid with, the
+	// pipeline strategy.
+	Pipeline []Stage `yaml:"pipeline"`
+
+	// Plugin configures the plugin strategy. It is required by, and only valid with, the plugin strategy.
+	Plugin *Plugin `yaml:"plugin"`
 }
 
 func (c *Criteria) validate() error {
@@ -102,24 +249,63 @@ func (c *Criteria) validate() error {
 		return fmt.Errorf("Criteria must have a Namespace")
 	}
 
+	if err := c.validateKind(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
 	if c.MinAge <= 0 {
 		c.MinAge = DefaultMinAge
 	}
 
-	if c.MaxAge <= 0 {
-		c.MaxAge = DefaultMaxAge
+	// cleanup Kinds only look at MinAge
+	if !c.Kind.IsCleanup() {
+		if c.MaxAge <= 0 {
+			c.MaxAge = DefaultMaxAge
+		}
+
+		if c.MaxAge <= c.MinAge {
+			return fmt.Errorf("MaxAge: %ds must be less then MinAge: %ds", c.MaxAge, c.MinAge)
+		}
 	}
 
-	if c.MaxAge <= c.MinAge {
-		return fmt.Errorf("MaxAge: %ds must be less then MinAge: %ds", c.MaxAge, c.MinAge)
+	if c.Expression != "" {
+		if _, err := expr.Compile(c.Expression); err != nil {
+			return fmt.Errorf("Criteria %s: %s", c.Name, err)
+		}
 	}
 
 	if c.Strategy == "" {
 		c.Strategy = DefaultStrategy
 	}
 
-	if c.Limit <= 0 {
-		c.Limit = DefaultLimit
+	if c.Strategy == StrategyTriggered && !c.Triggers.Enabled() {
+		return fmt.Errorf("Criteria %s: strategy %s requires triggers", c.Name, c.Strategy)
+	}
+
+	if err := c.validatePipeline(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	if (c.Strategy == StrategyPlugin) != (c.Plugin != nil) {
+		return fmt.Errorf("Criteria %s: strategy %s requires, and is the only strategy allowing, a Plugin", c.Name, StrategyPlugin)
+	}
+
+	if c.Plugin != nil {
+		if err := c.Plugin.validate(); err != nil {
+			return fmt.Errorf("Criteria %s: %s", c.Name, err)
+		}
+	}
+
+	if c.Limit.Percent > 100 {
+		return fmt.Errorf("Criteria %s: Limit %s must not be greater then 100%%", c.Name, c.Limit)
+	}
+
+	if c.Limit.Count <= 0 && c.Limit.Percent <= 0 {
+		c.Limit = Limit{Count: DefaultLimit}
+	}
+
+	if err := c.LimitPolicy.validate(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
 	}
 
 	if c.GracePeriod <= 0 {
@@ -130,6 +316,32 @@ func (c *Criteria) validate() error {
 		c.CoolDown = DefaultCoolDown
 	}
 
+	if err := c.Triggers.validate(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	if err := c.Random.validate(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	if err := c.Topology.validate(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	if err := c.Diagnostics.validate(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	if err := c.validateAction(); err != nil {
+		return fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	if c.PreKick != nil {
+		if err := c.PreKick.validate(); err != nil {
+			return fmt.Errorf("Criteria %s: %s", c.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -146,4 +358,34 @@ const (
 	// StrategyImmediate kicks any pod that is over MaxAge regardless of the state of other pods. This is a fairly
 	// drastic approach and should be used with caution.
 	StrategyImmediate = "immediate"
+	// StrategyRandom kicks randomly chosen pods older than MinAge, firing with a configurable probability each
+	// evaluation. It is intended for lightweight chaos testing.
+	StrategyRandom = "random"
+	// StrategyTriggered kicks the oldest pods for which a trigger has fired once they are older than MinAge, regardless
+	// of MaxAge. It requires Triggers to be configured.
+	StrategyTriggered = "triggered"
+	// StrategyCleanup deletes pods that have been in the state targeted by a cleanup Kind for longer than MinAge, oldest
+	// first. It is selected automatically for cleanup kinds without a strategy.
+	StrategyCleanup = "cleanup"
+	// StrategyPipeline composes its evaluation from the registered stages and filters listed in Pipeline. Pods are
+	// always restricted to those matched by the criteria before the first stage, and the selection to Limit and CoolDown.
+	StrategyPipeline = "pipeline"
+	// StrategyPlugin delegates selection to an out of process plugin configured by Plugin. Plugins only ever see, and
+	// can only select, pods matched by the criteria; a failing plugin selects nothing.
+	StrategyPlugin = "plugin"
+)
+
+// Overlap defines how a pod selected by more than one criteria in the same evaluation is resolved. Criteria that match
+// the pod but did not select it, e.g. because they are cooling down, are not considered. Regardless of resolution a pod
+// is kicked at most once per evaluation.
+type Overlap string
+
+const (
+	// OverlapFirst lets only the first selecting criteria in config order kick the pod.
+	OverlapFirst = "first"
+	// OverlapPriority lets only the selecting criteria with the highest Priority kick the pod, falling back to config
+	// order for equal priorities.
+	OverlapPriority = "priority"
+	// OverlapRefuse refuses to kick the pod from any of the selecting criteria.
+	OverlapRefuse = "refuse"
 )
@@ -0,0 +1,72 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultDiagnosticsMaxBytes is the default MaxBytes if one is not provided in a Diagnostics Object
+	DefaultDiagnosticsMaxBytes = 10 * 1024 * 1024
+
+	// DefaultDiagnosticsLogBytes is the default LogBytes if one is not provided in a Diagnostics Object
+	DefaultDiagnosticsLogBytes = 1024 * 1024
+
+	// DefaultDiagnosticsRetention is the default Retention in seconds if one is not provided in a Diagnostics Object
+	DefaultDiagnosticsRetention = 7 * 24 * 60 * 60
+)
+
+// Diagnostics captures the state of a pod before it is kicked: its spec and status, its recent events, and the current
+// and previous logs of each container. The capture is saved as a gzipped tarball named after the pod and the kick time.
+// A failed capture is logged and does not prevent the kick.
+type Diagnostics struct {
+	// Dir is the directory tarballs are written to. Diagnostics are disabled if left empty.
+	Dir string `yaml:"dir"`
+
+	// MaxBytes caps the uncompressed size of a capture; files that no longer fit are truncated or left out. Defaults to
+	// DefaultDiagnosticsMaxBytes if not provided or <= 0.
+	MaxBytes int64 `yaml:"maxBytes"`
+
+	// LogBytes caps the size of each captured log. Defaults to DefaultDiagnosticsLogBytes if not provided or <= 0.
+	LogBytes int64 `yaml:"logBytes"`
+
+	// Retention is the duration in seconds tarballs are kept before being removed. Defaults to
+	// DefaultDiagnosticsRetention if not provided or <= 0.
+	Retention int64 `yaml:"retention"`
+
+	// MaxFiles is the maximum number of tarballs kept in Dir, the oldest being removed first. If not provided or <= 0
+	// only Retention applies.
+	MaxFiles int64 `yaml:"maxFiles"`
+}
+
+// Enabled reports whether diagnostics are captured.
+func (d Diagnostics) Enabled() bool {
+	return d.Dir != ""
+}
+
+func (d *Diagnostics) validate() error {
+	if !d.Enabled() {
+		return nil
+	}
+
+	if d.MaxBytes <= 0 {
+		d.MaxBytes = DefaultDiagnosticsMaxBytes
+	}
+
+	if d.LogBytes <= 0 {
+		d.LogBytes = DefaultDiagnosticsLogBytes
+	}
+
+	if d.LogBytes > d.MaxBytes {
+		return fmt.Errorf("Diagnostics LogBytes %d must not be greater then MaxBytes %d", d.LogBytes, d.MaxBytes)
+	}
+
+	if d.Retention <= 0 {
+		d.Retention = DefaultDiagnosticsRetention
+	}
+
+	if d.MaxFiles < 0 {
+		d.MaxFiles = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultHookTimeout is the default Timeout in seconds if one is not provided in a Hook Object
+	DefaultHookTimeout = 30
+
+	// DefaultHookOnFailure is the default OnFailure if one is not provided in a Hook Object
+	DefaultHookOnFailure = HookProceed
+)
+
+// HookFailurePolicy defines whether a kick proceeds when its hook fails
+type HookFailurePolicy string
+
+const (
+	// HookProceed kicks the pod even though its hook failed or timed out.
+	HookProceed = "proceed"
+	// HookAbort does not kick the pod when its hook failed or timed out. The kick is recorded as failed.
+	HookAbort = "abort"
+)
+
+// Hook is a command executed inside a container of a pod through the pods/exec subresource, e.g. to drain it before it
+// is kicked. The hook fails if the command exits non zero or does not finish within Timeout.
+type Hook struct {
+	// Container is the name of the container the command is executed in. If left empty the first container is used.
+	Container string `yaml:"container"`
+
+	// Command is the command and its arguments. It is not run in a shell.
+	// This is a required field
+	Command []string `yaml:"command"`
+
+	// Timeout is the duration in seconds the command may run. Defaults to DefaultHookTimeout if not provided or <= 0.
+	Timeout int64 `yaml:"timeout"`
+
+	// OnFailure is the policy applied when the hook fails: proceed or abort. Defaults to DefaultHookOnFailure if left
+	// empty.
+	OnFailure HookFailurePolicy `yaml:"onFailure"`
+}
+
+func (h *Hook) validate() error {
+	if len(h.Command) <= 0 {
+		return fmt.Errorf("Hook must have a Command")
+	}
+
+	if h.Timeout <= 0 {
+		h.Timeout = DefaultHookTimeout
+	}
+
+	switch h.OnFailure {
+	case "":
+		h.OnFailure = DefaultHookOnFailure
+	case HookProceed, HookAbort:
+	default:
+		return fmt.Errorf("Hook OnFailure '%s' is unknown", h.OnFailure)
+	}
+
+	return nil
+}
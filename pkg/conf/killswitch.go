@@ -0,0 +1,34 @@
+package conf
+
+import (
+	"fmt"
+)
+
+// KillSwitch defines a ConfigMap that is watched to pause kicking without redeploying. The ConfigMap may hold the keys:
+//
+//	pause: "true"          pauses all kicking
+//	pauseCriteria: "a,b"   pauses kicking by the named criteria
+//	dryRun: "true"         forces dry run mode
+//
+// A missing ConfigMap leaves kicking enabled.
+type KillSwitch struct {
+	// Name is the name of the ConfigMap. If left empty the kill switch is disabled.
+	Name string `yaml:"name"`
+
+	// Namespace is the namespace of the ConfigMap.
+	// This is a required field if Name is set
+	Namespace string `yaml:"namespace"`
+}
+
+// Enabled reports whether a kill switch ConfigMap is configured.
+func (k KillSwitch) Enabled() bool {
+	return k.Name != ""
+}
+
+func (k *KillSwitch) validate() error {
+	if k.Enabled() && k.Namespace == "" {
+		return fmt.Errorf("KillSwitch must have a Namespace")
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultKind is the default Kind if one is not provided in a Criteria Object
+	DefaultKind = KindRunning
+)
+
+// Kind defines the state of the pods a criteria targets
+type Kind string
+
+const (
+	// KindRunning targets running pods that are not terminating. Its pods are recycled by the configured Strategy.
+	KindRunning = "running"
+	// KindTerminating targets pods that are still terminating MinAge seconds after their deletion deadline, e.g.
+	// because of a finalizer or a dead node. They are force deleted with a grace period of 0.
+	KindTerminating = "terminating"
+	// KindFailed targets Failed pods, including Evicted ones, that finished at least MinAge seconds ago. They are
+	// deleted.
+	KindFailed = "failed"
+	// KindSucceeded targets Succeeded pods, e.g. of Jobs, that finished at least MinAge seconds ago. They are deleted.
+	KindSucceeded = "succeeded"
+)
+
+// IsCleanup reports whether the Kind targets pods to clean up rather than running pods to recycle.
+func (k Kind) IsCleanup() bool {
+	return k == KindTerminating || k == KindFailed || k == KindSucceeded
+}
+
+func (c *Criteria) validateKind() error {
+	switch c.Kind {
+	case "":
+		c.Kind = DefaultKind
+	case KindRunning, KindTerminating, KindFailed, KindSucceeded:
+	default:
+		return fmt.Errorf("Kind '%s' is unknown", c.Kind)
+	}
+
+	if !c.Kind.IsCleanup() {
+		if c.Strategy == StrategyCleanup {
+			return fmt.Errorf("strategy %s requires a Kind of %s, %s or %s", StrategyCleanup, KindTerminating, KindFailed, KindSucceeded)
+		}
+
+		return nil
+	}
+
+	switch c.Strategy {
+	case "":
+		c.Strategy = StrategyCleanup
+	case StrategyCleanup, StrategyPipeline, StrategyPlugin:
+	default:
+		return fmt.Errorf("Kind %s requires strategy %s, %s or %s", c.Kind, StrategyCleanup, StrategyPipeline, StrategyPlugin)
+	}
+
+	if c.Action == ActionQuarantine {
+		return fmt.Errorf("Kind %s does not support Action %s", c.Kind, ActionQuarantine)
+	}
+
+	if c.PreKick != nil {
+		return fmt.Errorf("Kind %s does not support a PreKick hook", c.Kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package conf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Limit is the maximum number of pods a criteria kicks per evaluation. In YAML it is either an absolute count, e.g.
+// `limit: 2`, or a percentage of the fleet, e.g. `limit: 10%`.
+type Limit struct {
+	// Count is the absolute limit. It is used when Percent is 0.
+	Count int64
+
+	// Percent is the limit as a percentage, between 0 and 100, of the fleet defined by LimitPolicy.Of.
+	Percent float64
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *Limit) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var count int64
+	if err := unmarshal(&count); err == nil {
+		*l = Limit{Count: count}
+		return nil
+	}
+
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return fmt.Errorf("limit must be a count or a percentage: %s", err)
+	}
+
+	if !strings.HasSuffix(s, "%") {
+		return fmt.Errorf("limit '%s' must be a count or a percentage", s)
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return fmt.Errorf("limit '%s' is not a valid percentage: %s", s, err)
+	}
+
+	*l = Limit{Percent: percent}
+	return nil
+}
+
+// IsPercent reports whether the Limit is relative to the fleet size.
+func (l Limit) IsPercent() bool {
+	return l.Percent > 0
+}
+
+func (l Limit) String() string {
+	if l.IsPercent() {
+		return strconv.FormatFloat(l.Percent, 'g', -1, 64) + "%"
+	}
+
+	return strconv.FormatInt(l.Count, 10)
+}
+
+const (
+	// DefaultLimitOf is the default Of if one is not provided in a LimitPolicy Object
+	DefaultLimitOf = LimitOfMatched
+
+	// DefaultLimitRounding is the default Rounding if one is not provided in a LimitPolicy Object
+	DefaultLimitRounding = LimitRoundDown
+
+	// DefaultLimitMin is the default Min if one is not provided in a LimitPolicy Object
+	DefaultLimitMin = 1
+)
+
+const (
+	// LimitOfMatched makes a percentage Limit relative to the number of pods matched by the criteria
+	LimitOfMatched = "matched"
+	// LimitOfReplicas makes a percentage Limit relative to the desired replicas of the owners of the matched pods
+	LimitOfReplicas = "replicas"
+
+	// LimitRoundDown rounds a percentage Limit down
+	LimitRoundDown = "down"
+	// LimitRoundUp rounds a percentage Limit up
+	LimitRoundUp = "up"
+	// LimitRoundNearest rounds a percentage Limit to the nearest count
+	LimitRoundNearest = "nearest"
+)
+
+// LimitPolicy defines how a percentage Limit is resolved to a count. It is ignored for absolute limits.
+type LimitPolicy struct {
+	// Of is the fleet the percentage is relative to: matched or replicas. Defaults to DefaultLimitOf if left empty.
+	Of string `yaml:"of"`
+
+	// Rounding is how a fractional count is rounded: down, up or nearest. Defaults to DefaultLimitRounding if left empty.
+	Rounding string `yaml:"rounding"`
+
+	// Min is the floor of the resolved count. Defaults to DefaultLimitMin if not provided or <= 0.
+	Min int64 `yaml:"min"`
+
+	// Max is the ceiling of the resolved count. If not provided or <= 0 there is no ceiling.
+	Max int64 `yaml:"max"`
+}
+
+func (p *LimitPolicy) validate() error {
+	switch p.Of {
+	case "":
+		p.Of = DefaultLimitOf
+	case LimitOfMatched, LimitOfReplicas:
+	default:
+		return fmt.Errorf("LimitPolicy Of '%s' is unknown", p.Of)
+	}
+
+	switch p.Rounding {
+	case "":
+		p.Rounding = DefaultLimitRounding
+	case LimitRoundDown, LimitRoundUp, LimitRoundNearest:
+	default:
+		return fmt.Errorf("LimitPolicy Rounding '%s' is unknown", p.Rounding)
+	}
+
+	if p.Min <= 0 {
+		p.Min = DefaultLimitMin
+	}
+
+	if p.Max > 0 && p.Max < p.Min {
+		return fmt.Errorf("LimitPolicy Max: %d must not be less then Min: %d", p.Max, p.Min)
+	}
+
+	return nil
+}
+
+// ResolveLimit returns the number of pods the Criteria may kick given a fleet of the passed size.
+func (c Criteria) ResolveLimit(fleet int) int64 {
+	if !c.Limit.IsPercent() {
+		return c.Limit.Count
+	}
+
+	exact := float64(fleet) * c.Limit.Percent / 100
+	var count int64
+	switch c.LimitPolicy.Rounding {
+	case LimitRoundUp:
+		count = int64(math.Ceil(exact))
+	case LimitRoundNearest:
+		count = int64(math.Floor(exact + 0.5))
+	default:
+		count = int64(math.Floor(exact))
+	}
+
+	if count < c.LimitPolicy.Min {
+		count = c.LimitPolicy.Min
+	}
+
+	if c.LimitPolicy.Max > 0 && count > c.LimitPolicy.Max {
+		count = c.LimitPolicy.Max
+	}
+
+	return count
+}
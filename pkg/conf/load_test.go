@@ -0,0 +1,97 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfExample(t *testing.T) {
+	c, err := LoadConf(filepath.Join("..", "..", "kicker.example.yaml"))
+	if err != nil {
+		t.Fatalf("error loading the example config: %s", err)
+	}
+
+	for _, criteria := range c.Criteria {
+		if criteria.Kind.IsCleanup() && criteria.MaxAge != 0 {
+			t.Errorf("expected cleanup criteria %s to have no MaxAge, got %d", criteria.Name, criteria.MaxAge)
+		}
+	}
+}
+
+func TestCriteriaAges(t *testing.T) {
+	cases := []struct {
+		name   string
+		yaml   string
+		maxAge int64
+		err    string
+	}{
+		{
+			name:   "running defaults MaxAge",
+			yaml:   "kind: running\nminAge: 600",
+			maxAge: DefaultMaxAge,
+		},
+		{
+			name: "running MinAge past the default MaxAge",
+			yaml: "kind: running\nminAge: 86400",
+			err:  "MaxAge",
+		},
+		{
+			name: "running MaxAge below MinAge",
+			yaml: "kind: running\nminAge: 600\nmaxAge: 300",
+			err:  "MaxAge",
+		},
+		{
+			name: "failed MinAge past the default MaxAge",
+			yaml: "kind: failed\nminAge: 86400",
+		},
+		{
+			name:   "succeeded ignores MaxAge",
+			yaml:   "kind: succeeded\nminAge: 600\nmaxAge: 300",
+			maxAge: 300,
+		},
+		{
+			name: "unknown kind is reported first",
+			yaml: "kind: evicted\nminAge: 86400",
+			err:  "Kind 'evicted' is unknown",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := loadString(t, "criteria:\n- name: web\n  namespace: default\n  "+strings.Replace(tc.yaml, "\n", "\n  ", -1)+"\n")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing '%s', got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := c.Criteria[0].MaxAge; got != tc.maxAge {
+				t.Errorf("expected MaxAge %d, got %d", tc.maxAge, got)
+			}
+		})
+	}
+}
+
+// loadString loads the passed YAML as a config file.
+func loadString(t *testing.T, content string) (Conf, error) {
+	dir, err := ioutil.TempDir("", "kicker-conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, DefaultConfigFileName)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return LoadConf(path)
+}
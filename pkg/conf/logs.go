@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// DefaultLogTailLines is the default TailLines if one is not provided in a LogTrigger Object
+	DefaultLogTailLines = 500
+
+	// DefaultLogMatches is the default Matches if one is not provided in a LogTrigger Object
+	DefaultLogMatches = 1
+
+	// DefaultLogConcurrency is the default Concurrency if one is not provided in a LogTrigger Object
+	DefaultLogConcurrency = 5
+
+	// DefaultLogCacheFor is the default CacheFor in seconds if one is not provided in a LogTrigger Object
+	DefaultLogCacheFor = 60
+)
+
+// LogTrigger fires for pods whose container logged a line matching Pattern at least Matches times within the most
+// recent TailLines lines or Since seconds.
+type LogTrigger struct {
+	// Container is the name of the container whose logs are read.
+	// This is a required field
+	Container string `yaml:"container"`
+
+	// Pattern is the regular expression matched against each log line.
+	// This is a required field
+	Pattern string `yaml:"pattern"`
+
+	// Matches is the number of matching lines required to fire. Defaults to DefaultLogMatches if not provided or <= 0.
+	Matches int64 `yaml:"matches"`
+
+	// TailLines is the number of most recent lines read. Defaults to DefaultLogTailLines if not provided or <= 0.
+	TailLines int64 `yaml:"tailLines"`
+
+	// Since limits the lines read to those logged in the last Since seconds. If not provided or <= 0 only TailLines
+	// applies.
+	Since int64 `yaml:"since"`
+
+	// Concurrency is the maximum number of log reads in flight at once. Defaults to DefaultLogConcurrency if not
+	// provided or <= 0.
+	Concurrency int64 `yaml:"concurrency"`
+
+	// CacheFor is the duration in seconds the result for a pod is reused before its logs are read again. Defaults to
+	// DefaultLogCacheFor if not provided or <= 0.
+	CacheFor int64 `yaml:"cacheFor"`
+}
+
+func (l *LogTrigger) validate() error {
+	if l.Container == "" {
+		return fmt.Errorf("LogTrigger must have a Container")
+	}
+
+	if l.Pattern == "" {
+		return fmt.Errorf("LogTrigger must have a Pattern")
+	}
+
+	if _, err := regexp.Compile(l.Pattern); err != nil {
+		return fmt.Errorf("LogTrigger Pattern '%s' is invalid: %s", l.Pattern, err)
+	}
+
+	if l.Matches <= 0 {
+		l.Matches = DefaultLogMatches
+	}
+
+	if l.TailLines <= 0 {
+		l.TailLines = DefaultLogTailLines
+	}
+
+	if l.Since < 0 {
+		l.Since = 0
+	}
+
+	if l.Concurrency <= 0 {
+		l.Concurrency = DefaultLogConcurrency
+	}
+
+	if l.CacheFor <= 0 {
+		l.CacheFor = DefaultLogCacheFor
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package conf
+
+// Metrics defines an HTTP server exposing only the Prometheus metrics, so they can be scraped without enabling the API.
+type Metrics struct {
+	// Addr is the address the server listens on, e.g. ":9090". If left empty metrics are only served by the API.
+	Addr string `yaml:"addr"`
+}
+
+// Enabled reports whether the metrics server is configured.
+func (m Metrics) Enabled() bool {
+	return m.Addr != ""
+}
@@ -0,0 +1,65 @@
+package conf
+
+import (
+	"fmt"
+
+	"github.com/curlymon/kicker/pkg/expr"
+)
+
+// Stage is a single step of a pipeline strategy. It references either a registered stage or a registered filter by
+// name. Stages are applied in order, the output of one being the input of the next; stages that wrap an evaluator, e.g.
+// coolDown or limitFor, wrap every stage that follows them.
+type Stage struct {
+	// Stage is the name of a registered stage. Exactly one of Stage and Filter must be set.
+	Stage string `yaml:"stage"`
+
+	// Filter is the name of a registered filter. Only pods it matches are passed on.
+	Filter string `yaml:"filter"`
+
+	// Not inverts Filter. It is ignored for stages.
+	Not bool `yaml:"not"`
+
+	// Params are the parameters of the stage or filter. Durations are given as seconds or as a Go duration, e.g. "10m".
+	Params map[string]string `yaml:"params"`
+}
+
+func (s *Stage) validate() error {
+	if (s.Stage == "") == (s.Filter == "") {
+		return fmt.Errorf("Pipeline stage must set exactly one of Stage and Filter")
+	}
+
+	if s.Stage != "" && s.Not {
+		return fmt.Errorf("Pipeline stage %s: Not is only valid for filters", s.Stage)
+	}
+
+	// expressions are compiled here so that their errors surface in validation rather than when the strategy is built
+	if s.Filter == "expression" {
+		if _, err := expr.Compile(s.Params["expr"]); err != nil {
+			return fmt.Errorf("Pipeline filter %s: %s", s.Filter, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Criteria) validatePipeline() error {
+	if c.Strategy != StrategyPipeline {
+		if len(c.Pipeline) > 0 {
+			return fmt.Errorf("Pipeline requires strategy %s", StrategyPipeline)
+		}
+
+		return nil
+	}
+
+	if len(c.Pipeline) <= 0 {
+		return fmt.Errorf("strategy %s requires a Pipeline", StrategyPipeline)
+	}
+
+	for i := range c.Pipeline {
+		if err := c.Pipeline[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
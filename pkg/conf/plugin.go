@@ -0,0 +1,41 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultPluginTimeout is the default Timeout in seconds if one is not provided in a Plugin Object
+	DefaultPluginTimeout = 10
+)
+
+// Plugin configures the plugin strategy, which delegates selection to an out of process plugin speaking the gRPC
+// protocol of package plugin. The plugin is either launched by kicker or already listening at Address.
+type Plugin struct {
+	// Command is the plugin binary and its arguments. Kicker launches it with the socket it must listen on in the
+	// KICKER_PLUGIN_SOCKET environment variable and relaunches it if it exits. Exactly one of Command and Address must be
+	// set.
+	Command []string `yaml:"command"`
+
+	// Address is the host:port, or unix:// followed by the socket path, of an already running plugin.
+	Address string `yaml:"address"`
+
+	// Params are passed to the plugin with every evaluation.
+	Params map[string]string `yaml:"params"`
+
+	// Timeout is the timeout in seconds of a single evaluation, including launching or connecting to the plugin.
+	// Defaults to DefaultPluginTimeout if not provided or <= 0.
+	Timeout int64 `yaml:"timeout"`
+}
+
+func (p *Plugin) validate() error {
+	if (len(p.Command) > 0) == (p.Address != "") {
+		return fmt.Errorf("Plugin must set exactly one of Command and Address")
+	}
+
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultPluginTimeout
+	}
+
+	return nil
+}
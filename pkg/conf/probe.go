@@ -0,0 +1,90 @@
+package conf
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// DefaultProbePath is the default Path if one is not provided in a ProbeTrigger Object
+	DefaultProbePath = "/"
+
+	// DefaultProbeTimeout is the default Timeout in seconds if one is not provided in a ProbeTrigger Object
+	DefaultProbeTimeout = 1
+
+	// DefaultProbeFailures is the default Failures if one is not provided in a ProbeTrigger Object
+	DefaultProbeFailures = 3
+
+	// DefaultProbeConcurrency is the default Concurrency if one is not provided in a ProbeTrigger Object
+	DefaultProbeConcurrency = 10
+)
+
+// ProbeTrigger fires for pods that fail an HTTP GET against their pod IP on Failures consecutive evaluations. A probe
+// fails when the request errors, times out, returns a status not in Status, or returns a body not matching Body.
+type ProbeTrigger struct {
+	// Port is the port probed on the pod IP.
+	// This is a required field
+	Port int32 `yaml:"port"`
+
+	// Path is the path requested. Defaults to DefaultProbePath if left empty.
+	Path string `yaml:"path"`
+
+	// HTTPS probes with TLS. Certificates are not verified.
+	HTTPS bool `yaml:"https"`
+
+	// Headers are added to every request.
+	Headers map[string]string `yaml:"headers"`
+
+	// Status is the list of expected status codes. If left empty any status from 200 to 399 succeeds.
+	Status []int `yaml:"status"`
+
+	// Body is a regular expression the response body must match. If left empty the body is not checked.
+	Body string `yaml:"body"`
+
+	// Timeout is the timeout in seconds of a single probe. Defaults to DefaultProbeTimeout if not provided or <= 0.
+	Timeout int64 `yaml:"timeout"`
+
+	// Failures is the number of consecutive failed probes before the trigger fires. Defaults to DefaultProbeFailures if
+	// not provided or <= 0.
+	Failures int64 `yaml:"failures"`
+
+	// Concurrency is the maximum number of probes in flight at once. Defaults to DefaultProbeConcurrency if not
+	// provided or <= 0.
+	Concurrency int64 `yaml:"concurrency"`
+}
+
+func (p *ProbeTrigger) validate() error {
+	if p.Port <= 0 || p.Port > 65535 {
+		return fmt.Errorf("ProbeTrigger Port %d is invalid", p.Port)
+	}
+
+	if p.Path == "" {
+		p.Path = DefaultProbePath
+	}
+
+	for _, status := range p.Status {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("ProbeTrigger Status %d is invalid", status)
+		}
+	}
+
+	if p.Body != "" {
+		if _, err := regexp.Compile(p.Body); err != nil {
+			return fmt.Errorf("ProbeTrigger Body '%s' is invalid: %s", p.Body, err)
+		}
+	}
+
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultProbeTimeout
+	}
+
+	if p.Failures <= 0 {
+		p.Failures = DefaultProbeFailures
+	}
+
+	if p.Concurrency <= 0 {
+		p.Concurrency = DefaultProbeConcurrency
+	}
+
+	return nil
+}
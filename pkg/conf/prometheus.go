@@ -0,0 +1,87 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// DefaultPrometheusTimeout is the default Timeout in seconds if one is not provided in a Prometheus Object
+	DefaultPrometheusTimeout = 10
+
+	// DefaultPrometheusPodLabel is the default PodLabel if one is not provided in a PrometheusTrigger Object
+	DefaultPrometheusPodLabel = "pod"
+
+	// DefaultPrometheusNamespaceLabel is the default NamespaceLabel if one is not provided in a PrometheusTrigger Object
+	DefaultPrometheusNamespaceLabel = "namespace"
+)
+
+// Prometheus defines how to reach the Prometheus HTTP API.
+type Prometheus struct {
+	// URL is the base URL of the Prometheus server, e.g. http://prometheus.monitoring:9090
+	URL string `yaml:"url"`
+
+	// Headers are additional HTTP headers sent with every query, e.g. Authorization.
+	Headers map[string]string `yaml:"headers"`
+
+	// Timeout is the timeout in seconds of a single query. Defaults to DefaultPrometheusTimeout if not provided or <= 0.
+	Timeout int64 `yaml:"timeout"`
+}
+
+func (p *Prometheus) validate() error {
+	if p.URL == "" {
+		return nil
+	}
+
+	if _, err := url.Parse(p.URL); err != nil {
+		return fmt.Errorf("Prometheus URL '%s' is invalid: %s", p.URL, err)
+	}
+
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultPrometheusTimeout
+	}
+
+	return nil
+}
+
+// PrometheusTrigger fires for pods whose series returned by Query stays above Threshold for a sustained duration. The
+// query must return an instant vector with one series per pod, labelled with the pod's name and namespace.
+type PrometheusTrigger struct {
+	// Query is the PromQL query, e.g. sum by (namespace, pod) (rate(http_requests_errors_total[5m])).
+	// This is a required field
+	Query string `yaml:"query"`
+
+	// Threshold is the value a pod's series must exceed.
+	Threshold float64 `yaml:"threshold"`
+
+	// For is the duration in seconds the threshold must be exceeded before the trigger fires. If not provided or <= 0
+	// the trigger fires on the first observation.
+	For int64 `yaml:"for"`
+
+	// PodLabel is the series label holding the pod name. Defaults to DefaultPrometheusPodLabel if left empty.
+	PodLabel string `yaml:"podLabel"`
+
+	// NamespaceLabel is the series label holding the pod namespace. Defaults to DefaultPrometheusNamespaceLabel if left
+	// empty.
+	NamespaceLabel string `yaml:"namespaceLabel"`
+}
+
+func (p *PrometheusTrigger) validate() error {
+	if p.Query == "" {
+		return fmt.Errorf("PrometheusTrigger must have a Query")
+	}
+
+	if p.For < 0 {
+		p.For = 0
+	}
+
+	if p.PodLabel == "" {
+		p.PodLabel = DefaultPrometheusPodLabel
+	}
+
+	if p.NamespaceLabel == "" {
+		p.NamespaceLabel = DefaultPrometheusNamespaceLabel
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultRandomProbability is the default Probability if one is not provided in a Random Object
+	DefaultRandomProbability = 1.0
+
+	// DefaultRandomWeight is the default Weight if one is not provided in a Random Object
+	DefaultRandomWeight = RandomWeightUniform
+)
+
+const (
+	// RandomWeightUniform picks every eligible pod with equal likelihood
+	RandomWeightUniform = "uniform"
+	// RandomWeightAge picks eligible pods with a likelihood proportional to their age
+	RandomWeightAge = "age"
+)
+
+// Random configures the random strategy.
+type Random struct {
+	// Probability is the chance, between 0 and 1, that the strategy kicks anything in a given evaluation. Defaults to
+	// DefaultRandomProbability if not provided or <= 0.
+	Probability float64 `yaml:"probability"`
+
+	// Seed seeds the random source so that runs are reproducible. If not provided or 0 a time based seed is used.
+	Seed int64 `yaml:"seed"`
+
+	// Weight is how pods are picked: uniform or age. Defaults to DefaultRandomWeight if left empty.
+	Weight string `yaml:"weight"`
+}
+
+func (r *Random) validate() error {
+	if r.Probability <= 0 {
+		r.Probability = DefaultRandomProbability
+	}
+
+	if r.Probability > 1 {
+		return fmt.Errorf("Random Probability %g must not be greater then 1", r.Probability)
+	}
+
+	switch r.Weight {
+	case "":
+		r.Weight = DefaultRandomWeight
+	case RandomWeightUniform, RandomWeightAge:
+	default:
+		return fmt.Errorf("Random Weight '%s' is unknown", r.Weight)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package conf
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultTopologyKey is the default Key if one is not provided in a Topology Object
+	DefaultTopologyKey = "topology.kubernetes.io/zone"
+)
+
+// Topology limits how many pods a criteria kicks per node and per topology domain so that consecutive kicks do not
+// concentrate on one node or zone. A limit of 0 disables it.
+type Topology struct {
+	// MaxPerNode is the maximum number of pods kicked from any single node per Window.
+	MaxPerNode int64 `yaml:"maxPerNode"`
+
+	// MaxPerDomain is the maximum number of pods kicked from any single topology domain per Window. The domain of a pod
+	// is the value of the Key label of the node it is scheduled on.
+	MaxPerDomain int64 `yaml:"maxPerDomain"`
+
+	// Key is the node label defining topology domains. Defaults to DefaultTopologyKey if left empty.
+	Key string `yaml:"key"`
+
+	// Window is the period in seconds the limits apply over. If not provided or <= 0 the limits apply per evaluation.
+	Window int64 `yaml:"window"`
+}
+
+// Enabled reports whether any limit of the Topology is set.
+func (t Topology) Enabled() bool {
+	return t.MaxPerNode > 0 || t.MaxPerDomain > 0
+}
+
+func (t *Topology) validate() error {
+	if t.MaxPerNode < 0 || t.MaxPerDomain < 0 {
+		return fmt.Errorf("Topology limits must not be negative")
+	}
+
+	if t.Key == "" {
+		t.Key = DefaultTopologyKey
+	}
+
+	if t.Window < 0 {
+		t.Window = 0
+	}
+
+	return nil
+}
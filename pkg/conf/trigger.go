@@ -0,0 +1,130 @@
+package conf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Triggers restrict a criteria to pods that show a symptom, rather than only their age. A pod is a candidate when any
+// configured trigger has fired for it. Limits and spread intervals are still sized from every pod matched by the
+// criteria. Triggers work with any strategy; the triggered strategy kicks triggered pods as soon as they are older than
+// MinAge.
+type Triggers struct {
+	// Resources fires on container resource usage read from the metrics.k8s.io API.
+	Resources *ResourceTrigger `yaml:"resources"`
+
+	// Prometheus fires on per pod series returned by a PromQL query against Conf.Prometheus.
+	Prometheus *PrometheusTrigger `yaml:"prometheus"`
+
+	// Logs fires on a pattern appearing in the recent logs of a container.
+	Logs *LogTrigger `yaml:"logs"`
+
+	// Probe fires on failures of an HTTP probe run by kicker against the pod.
+	Probe *ProbeTrigger `yaml:"probe"`
+}
+
+// Enabled reports whether any trigger is configured.
+func (t Triggers) Enabled() bool {
+	return t.Resources != nil || t.Prometheus != nil || t.Logs != nil || t.Probe != nil
+}
+
+func (t *Triggers) validate() error {
+	if t.Resources != nil {
+		if err := t.Resources.validate(); err != nil {
+			return err
+		}
+	}
+
+	if t.Prometheus != nil {
+		if err := t.Prometheus.validate(); err != nil {
+			return err
+		}
+	}
+
+	if t.Logs != nil {
+		if err := t.Logs.validate(); err != nil {
+			return err
+		}
+	}
+
+	if t.Probe != nil {
+		if err := t.Probe.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResourceTrigger fires when a container's memory working set or CPU usage stays at or above a threshold for a
+// sustained duration. Thresholds are either absolute quantities, e.g. "512Mi" or "900m", or a percentage of the
+// container's limit, e.g. "90%".
+type ResourceTrigger struct {
+	// Container is the name of the container to watch. If left empty every container of the pod is watched.
+	Container string `yaml:"container"`
+
+	// Memory is the memory working set threshold.
+	Memory string `yaml:"memory"`
+
+	// CPU is the CPU usage threshold.
+	CPU string `yaml:"cpu"`
+
+	// For is the duration in seconds a threshold must be exceeded before the trigger fires. If not provided or <= 0 the
+	// trigger fires on the first observation.
+	For int64 `yaml:"for"`
+}
+
+func (r *ResourceTrigger) validate() error {
+	if r.Memory == "" && r.CPU == "" {
+		return fmt.Errorf("ResourceTrigger must have a Memory or CPU threshold")
+	}
+
+	if _, err := ParseThreshold(r.Memory); err != nil {
+		return fmt.Errorf("ResourceTrigger Memory: %s", err)
+	}
+
+	if _, err := ParseThreshold(r.CPU); err != nil {
+		return fmt.Errorf("ResourceTrigger CPU: %s", err)
+	}
+
+	if r.For < 0 {
+		r.For = 0
+	}
+
+	return nil
+}
+
+// Threshold is a parsed resource threshold, either an absolute quantity or a percentage of the container's limit.
+type Threshold struct {
+	// Quantity is the absolute threshold, nil when Percent is used
+	Quantity *resource.Quantity
+
+	// Percent is the threshold as a percentage of the container's limit, 0 when Quantity is used
+	Percent float64
+}
+
+// ParseThreshold parses a threshold string. An empty string yields a nil Threshold.
+func ParseThreshold(s string) (*Threshold, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent <= 0 {
+			return nil, fmt.Errorf("'%s' is not a valid percentage", s)
+		}
+
+		return &Threshold{Percent: percent}, nil
+	}
+
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid quantity: %s", s, err)
+	}
+
+	return &Threshold{Quantity: &q}, nil
+}
@@ -0,0 +1,115 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+	"text/template"
+)
+
+const (
+	// DefaultWebhookTimeout is the default Timeout in seconds if one is not provided in a Webhook Object
+	DefaultWebhookTimeout = 10
+
+	// DefaultWebhookMaxRetries is the default MaxRetries if one is not provided in a Webhook Object
+	DefaultWebhookMaxRetries = 3
+
+	// DefaultWebhookQueueSize is the default QueueSize if one is not provided in a Webhook Object
+	DefaultWebhookQueueSize = 100
+)
+
+var webhookEvents = map[string]bool{
+	"kick":             true,
+	"kickFailed":       true,
+	"safeguardRefused": true,
+	"criteriaStuck":    true,
+}
+
+// Webhook defines an outbound HTTP endpoint that is notified of kicker events.
+type Webhook struct {
+	// URL is the endpoint events are POSTed to.
+	// This is a required field
+	URL string `yaml:"url"`
+
+	// Headers are additional HTTP headers sent with every request, e.g. Authorization.
+	Headers map[string]string `yaml:"headers"`
+
+	// Criteria limits notifications to events raised by the named criteria. If left empty events from all criteria are
+	// sent.
+	Criteria []string `yaml:"criteria"`
+
+	// Events limits notifications to the listed event kinds: kick, kickFailed, safeguardRefused and criteriaStuck. If
+	// left empty all events are sent.
+	Events []string `yaml:"events"`
+
+	// Template is a text/template rendered with the event to build the request body. If left empty the event is sent
+	// as JSON.
+	Template string `yaml:"template"`
+
+	// Timeout is the timeout in seconds of a single request. Defaults to DefaultWebhookTimeout if not provided or <= 0.
+	Timeout int64 `yaml:"timeout"`
+
+	// MaxRetries is the number of times a failed request is retried with exponential backoff before the event is
+	// dropped. Defaults to DefaultWebhookMaxRetries if not provided or <= 0.
+	MaxRetries int64 `yaml:"maxRetries"`
+
+	// QueueSize is the number of events buffered for this webhook. Events raised while the queue is full are dropped
+	// so that a slow webhook never blocks evaluation. Defaults to DefaultWebhookQueueSize if not provided or <= 0.
+	QueueSize int64 `yaml:"queueSize"`
+}
+
+func (c *Conf) validateWebhooks() error {
+	names := map[string]bool{}
+	for _, cr := range c.Criteria {
+		names[cr.Name] = true
+	}
+
+	for i := range c.Webhooks {
+		if err := c.Webhooks[i].validate(); err != nil {
+			return err
+		}
+
+		for _, name := range c.Webhooks[i].Criteria {
+			if !names[name] {
+				return fmt.Errorf("Webhook '%s' references unknown Criteria %s", c.Webhooks[i].URL, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *Webhook) validate() error {
+	if w.URL == "" {
+		return fmt.Errorf("Webhook must have a URL")
+	}
+
+	if _, err := url.Parse(w.URL); err != nil {
+		return fmt.Errorf("Webhook URL '%s' is invalid: %s", w.URL, err)
+	}
+
+	for _, e := range w.Events {
+		if !webhookEvents[e] {
+			return fmt.Errorf("Webhook '%s' event '%s' is unknown", w.URL, e)
+		}
+	}
+
+	if w.Template != "" {
+		if _, err := template.New(w.URL).Parse(w.Template); err != nil {
+			return fmt.Errorf("Webhook '%s' template is invalid: %s", w.URL, err)
+		}
+	}
+
+	if w.Timeout <= 0 {
+		w.Timeout = DefaultWebhookTimeout
+	}
+
+	if w.MaxRetries <= 0 {
+		w.MaxRetries = DefaultWebhookMaxRetries
+	}
+
+	if w.QueueSize <= 0 {
+		w.QueueSize = DefaultWebhookQueueSize
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package conf
+
+import (
+	"testing"
+)
+
+func TestValidateWebhooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		webhooks []Webhook
+		err      string
+	}{
+		{
+			name: "no webhooks",
+		},
+		{
+			name:     "all criteria",
+			webhooks: []Webhook{{URL: "http://hooks.local/kicker"}},
+		},
+		{
+			name:     "known criteria",
+			webhooks: []Webhook{{URL: "http://hooks.local/kicker", Criteria: []string{"api", "web"}}},
+		},
+		{
+			name:     "unknown criteria",
+			webhooks: []Webhook{{URL: "http://hooks.local/kicker", Criteria: []string{"web", "wbe"}}},
+			err:      "Webhook 'http://hooks.local/kicker' references unknown Criteria wbe",
+		},
+		{
+			name:     "unknown event",
+			webhooks: []Webhook{{URL: "http://hooks.local/kicker", Events: []string{"kicked"}}},
+			err:      "Webhook 'http://hooks.local/kicker' event 'kicked' is unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Conf{Webhooks: tt.webhooks, Criteria: []Criteria{{Name: "web"}, {Name: "api"}}}
+			err := c.validateWebhooks()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("validateWebhooks() error = %s", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("validateWebhooks() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Ext is the file extension of diagnostics tarballs.
+const Ext = ".tar.gz"
+
+// Capturer captures the diagnostics of pods before they are kicked.
+type Capturer struct {
+	c         conf.Diagnostics
+	clientset kubernetes.Interface
+	store     Store
+}
+
+// New builds a Capturer for the passed conf.Diagnostics saving to a DirStore. It returns nil if diagnostics are not
+// enabled. A nil *Capturer is safe to use and captures nothing.
+func New(clientset kubernetes.Interface, c conf.Diagnostics) (*Capturer, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	store, err := NewDirStore(c.Dir, time.Duration(c.Retention)*time.Second, int(c.MaxFiles))
+	if err != nil {
+		return nil, fmt.Errorf("error opening diagnostics dir %s: %s", c.Dir, err)
+	}
+
+	return NewWithStore(clientset, c, store), nil
+}
+
+// NewWithStore builds a Capturer for the passed conf.Diagnostics saving to the passed Store.
+func NewWithStore(clientset kubernetes.Interface, c conf.Diagnostics, store Store) *Capturer {
+	return &Capturer{
+		c:         c,
+		clientset: clientset,
+		store:     store,
+	}
+}
+
+// Name returns the name of the tarball capturing the passed pod at now.
+func Name(pod v1.Pod, now time.Time) string {
+	return fmt.Sprintf("%s_%s_%s%s", pod.Namespace, pod.Name, now.UTC().Format("20060102T150405Z"), Ext)
+}
+
+// Capture saves the diagnostics of the passed pod and removes tarballs past their retention, returning where the
+// capture was saved. Parts of the capture that cannot be read are listed in errors.txt inside the tarball rather than
+// failing the capture.
+func (c *Capturer) Capture(pod v1.Pod, now time.Time) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	t := newTarball(c.c.MaxBytes, now)
+
+	spec, err := json.MarshalIndent(pod, "", "  ")
+	if err != nil {
+		t.fail("pod.json", err)
+	} else {
+		t.add("pod.json", spec)
+	}
+
+	events, err := c.events(pod)
+	if err != nil {
+		t.fail("events.json", err)
+	} else {
+		t.add("events.json", events)
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, previous := range []bool{false, true} {
+			name := "logs/" + container.Name + ".log"
+			if previous {
+				name = "logs/" + container.Name + ".previous.log"
+			}
+
+			logs, err := c.logs(pod, container.Name, previous, t.remaining())
+			if err != nil {
+				t.fail(name, err)
+				continue
+			}
+
+			t.add(name, logs)
+		}
+	}
+
+	data, err := t.close()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := c.store.Put(Name(pod, now), data)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.store.Cleanup(now); err != nil {
+		log.Printf("error cleaning up diagnostics: %s", err)
+	}
+
+	return path, nil
+}
+
+func (c *Capturer) events(pod v1.Pod) ([]byte, error) {
+	selector := fields.Set{
+		"involvedObject.name":      pod.Name,
+		"involvedObject.namespace": pod.Namespace,
+		"involvedObject.uid":       string(pod.UID),
+	}.AsSelector().String()
+
+	events, err := c.clientset.CoreV1().Events(pod.Namespace).List(metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(events.Items, "", "  ")
+}
+
+func (c *Capturer) logs(pod v1.Pod, container string, previous bool, remaining int64) ([]byte, error) {
+	limit := c.c.LogBytes
+	if remaining < limit {
+		limit = remaining
+	}
+
+	if limit <= 0 {
+		return nil, fmt.Errorf("capture reached its size cap of %d bytes", c.c.MaxBytes)
+	}
+
+	return c.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		LimitBytes: &limit,
+	}).DoRaw()
+}
+
+// tarball builds a gzipped tar in memory, capping the total size of the files added.
+type tarball struct {
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	max     int64
+	written int64
+	now     time.Time
+	errs    []string
+	err     error
+}
+
+func newTarball(max int64, now time.Time) *tarball {
+	t := &tarball{max: max, now: now}
+	t.gz = gzip.NewWriter(&t.buf)
+	t.tw = tar.NewWriter(t.gz)
+	return t
+}
+
+func (t *tarball) remaining() int64 {
+	return t.max - t.written
+}
+
+// add writes a file, truncating it to the remaining size.
+func (t *tarball) add(name string, data []byte) {
+	if int64(len(data)) > t.remaining() {
+		t.fail(name, fmt.Errorf("truncated from %d bytes to fit the size cap of %d bytes", len(data), t.max))
+		data = data[:t.remaining()]
+	}
+
+	t.write(name, data)
+	t.written += int64(len(data))
+}
+
+// fail records why a file is missing or incomplete.
+func (t *tarball) fail(name string, err error) {
+	t.errs = append(t.errs, fmt.Sprintf("%s: %s", name, err))
+}
+
+func (t *tarball) write(name string, data []byte) {
+	if t.err != nil {
+		return
+	}
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: t.now,
+	}
+
+	if t.err = t.tw.WriteHeader(hdr); t.err != nil {
+		return
+	}
+
+	_, t.err = t.tw.Write(data)
+}
+
+func (t *tarball) close() ([]byte, error) {
+	if len(t.errs) > 0 {
+		t.write("errors.txt", []byte(strings.Join(t.errs, "\n")+"\n"))
+	}
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	if err := t.tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := t.gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return t.buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package diagnostics
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Store persists diagnostics tarballs, e.g. to a local directory or an object store.
+type Store interface {
+	// Put saves the tarball under the passed name, returning where it was saved
+	Put(name string, data []byte) (string, error)
+
+	// Cleanup removes tarballs that are past their retention at now
+	Cleanup(now time.Time) error
+}
+
+// DirStore implements Store with a local directory.
+type DirStore struct {
+	dir       string
+	retention time.Duration
+	maxFiles  int
+}
+
+// NewDirStore builds a DirStore writing to dir, creating it if needed. Tarballs older than retention are removed on
+// Cleanup, as are the oldest tarballs beyond maxFiles if maxFiles > 0.
+func NewDirStore(dir string, retention time.Duration, maxFiles int) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DirStore{
+		dir:       dir,
+		retention: retention,
+		maxFiles:  maxFiles,
+	}, nil
+}
+
+// Put implements Store. The tarball is written to a temporary file first so that partial captures are never left under
+// their final name.
+func (d *DirStore) Put(name string, data []byte) (string, error) {
+	path := filepath.Join(d.dir, name)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Cleanup implements Store.
+func (d *DirStore) Cleanup(now time.Time) error {
+	infos, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	var kept []os.FileInfo
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), Ext) {
+			continue
+		}
+
+		if now.Sub(info.ModTime()) > d.retention {
+			d.remove(info.Name())
+			continue
+		}
+
+		kept = append(kept, info)
+	}
+
+	if d.maxFiles <= 0 || len(kept) <= d.maxFiles {
+		return nil
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].ModTime().Before(kept[j].ModTime())
+	})
+
+	for _, info := range kept[:len(kept)-d.maxFiles] {
+		d.remove(info.Name())
+	}
+
+	return nil
+}
+
+func (d *DirStore) remove(name string) {
+	if err := os.Remove(filepath.Join(d.dir, name)); err != nil && !os.IsNotExist(err) {
+		log.Printf("error removing diagnostics %s: %s", name, err)
+	}
+}
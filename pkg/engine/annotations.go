@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AnnotationSkip excludes a pod from every criteria when set to "true" on the pod or any of its owners.
+	AnnotationSkip = "kicker.io/skip"
+
+	// AnnotationEnabled makes a pod eligible for kicking when conf.Conf.OptIn is set. It must be "true" on the pod or
+	// its namespace.
+	AnnotationEnabled = "kicker.io/enabled"
+
+	// AnnotationPauseUntil excludes a pod from every criteria until the RFC3339 timestamp it holds. It may be set on
+	// the pod or any of its owners.
+	AnnotationPauseUntil = "kicker.io/pause-until"
+)
+
+// maxOwnerDepth bounds how far up the owner chain annotations are looked for, e.g. Pod -> ReplicaSet -> Deployment.
+const maxOwnerDepth = 3
+
+// annotationFilter removes pods that have opted out of, or not opted in to, kicking. It caches namespaces so it should
+// only be used for a single evaluation.
+type annotationFilter struct {
+	owners     *ownerCache
+	optIn      bool
+	now        time.Time
+	namespaces map[string]map[string]string
+}
+
+// filterAnnotated returns the pods matched by any criteria that are eligible for kicking according to their annotations
+// and those of their owners and namespaces. Pods whose owners cannot be retrieved are treated as ineligible.
+func (e *Engine) filterAnnotated(pods []v1.Pod) []v1.Pod {
+	f := &annotationFilter{
+		owners: e.owners,
+		optIn:  e.config.OptIn,
+		now:    time.Now(),
+	}
+
+	if f.optIn {
+		nsList, err := e.clientset.CoreV1().Namespaces().List(metav1.ListOptions{})
+		if err != nil {
+			log.Printf("error listing namespaces for opt in, no pods are eligible: %s", err)
+			return nil
+		}
+
+		f.namespaces = make(map[string]map[string]string, len(nsList.Items))
+		for _, ns := range nsList.Items {
+			f.namespaces[ns.Name] = ns.Annotations
+		}
+	}
+
+	out := make([]v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if !e.matchesAny(pod) {
+			continue
+		}
+
+		if reason := f.excluded(pod); reason != "" {
+			log.Printf("excluding pod %s: %s", podKey(pod), reason)
+			continue
+		}
+
+		out = append(out, pod)
+	}
+
+	return out
+}
+
+func (e *Engine) matchesAny(pod v1.Pod) bool {
+	for _, strat := range e.strats {
+		if strat.Matches(pod) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excluded returns why the passed pod is not eligible for kicking, or an empty string if it is.
+func (f *annotationFilter) excluded(pod v1.Pod) string {
+	if pod.Labels[LabelQuarantined] == "true" {
+		return "pod is quarantined"
+	}
+
+	if f.optIn && pod.Annotations[AnnotationEnabled] != "true" && f.namespaces[pod.Namespace][AnnotationEnabled] != "true" {
+		return fmt.Sprintf("neither pod nor namespace is annotated %s", AnnotationEnabled)
+	}
+
+	if reason := f.optedOut("pod", pod.Annotations); reason != "" {
+		return reason
+	}
+
+	ref := metav1.GetControllerOf(&pod)
+	for depth := 0; ref != nil && depth < maxOwnerDepth; depth++ {
+		owner, err := f.owners.get(pod.Namespace, ref)
+		if err != nil {
+			return fmt.Sprintf("error retrieving owner %s/%s: %s", ref.Kind, ref.Name, err)
+		}
+
+		if owner == nil {
+			break
+		}
+
+		if reason := f.optedOut(ref.Kind+"/"+ref.Name, owner.GetAnnotations()); reason != "" {
+			return reason
+		}
+
+		ref = metav1.GetControllerOf(owner)
+	}
+
+	return ""
+}
+
+// optedOut checks the skip and pause annotations of the named object.
+func (f *annotationFilter) optedOut(name string, annotations map[string]string) string {
+	if annotations[AnnotationSkip] == "true" {
+		return fmt.Sprintf("%s is annotated %s", name, AnnotationSkip)
+	}
+
+	if until, ok := annotations[AnnotationPauseUntil]; ok {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return fmt.Sprintf("%s has an invalid %s '%s': %s", name, AnnotationPauseUntil, until, err)
+		}
+
+		if f.now.Before(t) {
+			return fmt.Sprintf("%s is paused until %s", name, until)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/curlymon/kicker/pkg/api"
+	"github.com/curlymon/kicker/pkg/metrics"
+)
+
+// Ready implements api.Controller. The engine is ready once it has listed pods successfully.
+func (e *Engine) Ready() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.ready
+}
+
+func (e *Engine) setReady() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ready = true
+	metrics.ClusterReady.WithLabelValues(e.cluster).Set(1)
+}
+
+// Status implements api.Controller.
+func (e *Engine) Status() api.Status {
+	status := api.Status{
+		Cluster:    e.cluster,
+		Ready:      e.Ready(),
+		DryRun:     e.dryRun,
+		KillSwitch: e.kill.State(),
+		Criteria:   make([]api.CriteriaStatus, 0, len(e.configOrder)),
+	}
+
+	for _, strat := range e.configOrder {
+		sc := strat.Criteria()
+		status.Criteria = append(status.Criteria, api.CriteriaStatus{
+			Status:      strat.Status(),
+			Name:        sc.Name,
+			Strategy:    sc.Strategy,
+			Paused:      e.paused(sc.Name),
+			Overlapping: e.overlappingPods(sc.Name),
+		})
+	}
+
+	return status
+}
+
+// Pause implements api.Controller.
+func (e *Engine) Pause(criteria string) error {
+	return e.setPaused(criteria, true)
+}
+
+// Resume implements api.Controller.
+func (e *Engine) Resume(criteria string) error {
+	return e.setPaused(criteria, false)
+}
+
+// Trigger implements api.Controller. Triggers made while an evaluation is already pending are merged.
+func (e *Engine) Trigger() {
+	select {
+	case e.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Engine) setPaused(criteria string, paused bool) error {
+	if !e.hasCriteria(criteria) {
+		return fmt.Errorf("criteria '%s' does not exist", criteria)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pausedCriteria[criteria] = paused
+	log.Printf("criteria %s paused=%t", criteria, paused)
+	return nil
+}
+
+func (e *Engine) paused(criteria string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.pausedCriteria[criteria]
+}
+
+func (e *Engine) overlappingPods(criteria string) int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.overlapping[criteria]
+}
+
+func (e *Engine) hasCriteria(name string) bool {
+	for _, strat := range e.configOrder {
+		if strat.Criteria().Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,13 +1,27 @@
 package engine
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/curlymon/kicker/pkg/api"
+	"github.com/curlymon/kicker/pkg/audit"
+	"github.com/curlymon/kicker/pkg/budget"
 	"github.com/curlymon/kicker/pkg/client"
 	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/diagnostics"
+	"github.com/curlymon/kicker/pkg/hook"
+	"github.com/curlymon/kicker/pkg/killswitch"
+	"github.com/curlymon/kicker/pkg/metrics"
+	"github.com/curlymon/kicker/pkg/notify"
 	"github.com/curlymon/kicker/pkg/strategy"
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // this loads the gcp plugin (only required to authenticate against GKE clusters).
 )
 
@@ -19,55 +33,472 @@ func Exec(kickerConfPath string, dryRun bool) {
 		log.Fatalln(err)
 	}
 
-	interval := time.Duration(config.CheckInterval) * time.Second
+	g, err := NewGroup(config, dryRun)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer g.Close()
+
+	if config.API.Enabled() {
+		api.Serve(config.API, g)
+	}
+
+	if config.Metrics.Enabled() {
+		metrics.Serve(config.Metrics.Addr)
+	}
+
+	g.Run()
+}
+
+// Engine periodically evaluates a set of strategies against the pods in a cluster and kicks the pods they select.
+type Engine struct {
+	cluster   string
+	config    conf.Conf
+	dryRun    bool
+	interval  time.Duration
+	clientset kubernetes.Interface
+	strats    []*strategy.Strategy
+	audit     *audit.Log
+	notifier  *notify.Notifier
+	budget    *budget.Budget
+	kill      *killswitch.Switch
+	stop      chan struct{}
+	trigger   chan struct{}
+
+	// configOrder holds the same strategies as strats in the order their criteria appear in the config
+	configOrder []*strategy.Strategy
+
+	// failedCycles counts, per criteria name, the consecutive evaluations in which every kick failed
+	failedCycles map[string]int64
+
+	// nodes holds the labels of the cluster's nodes for topology aware strategies
+	nodes *nodeCache
+
+	// owners caches the owners of pods for the current evaluation
+	owners *ownerCache
+
+	// diagnostics holds, per criteria name, the Capturer run before its kicks
+	diagnostics map[string]*diagnostics.Capturer
+
+	// hooks runs the PreKick hooks of criteria, nil when no criteria has one
+	hooks *hook.Executor
+
+	// triggers holds the pods each criteria's triggers fired for in the current evaluation
+	triggers *triggerState
+
+	mu             sync.RWMutex
+	ready          bool
+	pausedCriteria map[string]bool
+
+	// overlapping counts, per criteria name, the pods also matched by another criteria at the last evaluation
+	overlapping map[string]int
+
+	// owned holds the shared resources the Engine closes, nil when they are owned by a Group
+	owned *shared
+}
+
+// shared holds the resources shared by the engines of every cluster
+type shared struct {
+	audit    *audit.Log
+	notifier *notify.Notifier
+}
+
+func openShared(config conf.Conf) (*shared, error) {
+	s := &shared{}
+	if config.AuditLog != "" {
+		var err error
+		if s.audit, err = audit.Open(config.AuditLog); err != nil {
+			return nil, err
+		}
+	}
+
+	s.notifier = notify.New(config.Webhooks)
+	return s, nil
+}
+
+func (s *shared) close() error {
+	s.notifier.Close()
+	return s.audit.Close()
+}
+
+// New builds an Engine from the passed conf.Conf, returning an error if unable to do so.
+func New(config conf.Conf, dryRun bool) (*Engine, error) {
+	s, err := openShared(config)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := newEngine("", config, dryRun, s)
+	if err != nil {
+		s.close()
+		return nil, err
+	}
 
+	e.owned = s
+	return e, nil
+}
+
+// newEngine builds the Engine of the named cluster using the passed shared resources.
+func newEngine(cluster string, config conf.Conf, dryRun bool, s *shared) (*Engine, error) {
 	clientset, err := client.New(config)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
 	}
 
-	strats, err := strategy.NewGroup(config.Criteria)
+	nodes := &nodeCache{}
+	owners := newOwnerCache(clientset)
+	triggers, err := newTriggerState(config, clientset)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
+	}
+
+	configOrder, err := strategy.NewGroup(config.Criteria, strategy.Lookups{
+		Nodes:     nodes.labels,
+		Replicas:  owners.replicas,
+		Triggered: triggers.triggered,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	warnStaticOverlap(config.Criteria)
+	strats := append([]*strategy.Strategy(nil), configOrder...)
+	sortByPriority(strats)
+
+	captures := map[string]*diagnostics.Capturer{}
+	for _, c := range config.Criteria {
+		if captures[c.Name], err = diagnostics.New(clientset, c.Diagnostics); err != nil {
+			closeStrategies(strats)
+			return nil, fmt.Errorf("Criteria %s: %s", c.Name, err)
+		}
+	}
+
+	var hooks *hook.Executor
+	for _, c := range config.Criteria {
+		if c.PreKick != nil && hooks == nil {
+			if hooks, err = hook.New(config); err != nil {
+				closeStrategies(strats)
+				return nil, err
+			}
+		}
+	}
+
+	kill := killswitch.New(clientset, config.KillSwitch, cluster)
+	stop := make(chan struct{})
+	go kill.Watch(stop)
+	metrics.ClusterReady.WithLabelValues(cluster).Set(0)
+
+	return &Engine{
+		cluster:   cluster,
+		config:    config,
+		dryRun:    dryRun,
+		interval:  time.Duration(config.CheckInterval) * time.Second,
+		clientset: clientset,
+		strats:    strats,
+		audit:     s.audit,
+		notifier:  s.notifier,
+		budget:    budget.New(config.Budget),
+		kill:      kill,
+		stop:      stop,
+		trigger:   make(chan struct{}, 1),
+
+		configOrder:  configOrder,
+		failedCycles: map[string]int64{},
+		nodes:        nodes,
+		owners:       owners,
+		triggers:     triggers,
+		diagnostics:  captures,
+		hooks:        hooks,
+
+		pausedCriteria: map[string]bool{},
+	}, nil
+}
+
+// Close releases any resources held by the Engine, including those of its strategies.
+func (e *Engine) Close() error {
+	close(e.stop)
+	closeStrategies(e.strats)
+	if e.owned == nil {
+		return nil
+	}
+
+	return e.owned.close()
+}
+
+func closeStrategies(strats []*strategy.Strategy) {
+	for _, strat := range strats {
+		if err := strat.Close(); err != nil {
+			log.Printf("error closing %s strategy: %s", strat.Criteria().Name, err)
+		}
 	}
+}
 
+// Run evaluates all strategies every check interval, or sooner when triggered. It never returns. Evaluations that cannot
+// list pods, or that panic, are logged and skipped so that other clusters keep being managed.
+func (e *Engine) Run() {
 	for {
-		podList, err := clientset.CoreV1().Pods("").List(metav1.ListOptions{})
+		e.cycle()
+
+		log.Printf("sleeping for %s", e.interval)
+		select {
+		case <-time.After(e.interval):
+		case <-e.trigger:
+			log.Println("evaluation triggered")
+		}
+	}
+}
+
+// cycle lists the pods of the cluster and runs a single evaluation.
+func (e *Engine) cycle() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("evaluation%s panicked: %v", e.clusterSuffix(), r)
+		}
+	}()
+
+	podList, err := e.clientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	if err != nil {
+		log.Printf("error listing pods%s, skipping evaluation: %s", e.clusterSuffix(), err)
+		return
+	}
+
+	e.setReady()
+	pods := podList.Items
+
+	log.Printf("There are %d pods in the cluster%s\n", len(pods), e.clusterSuffix())
+	log.Printf("Running %d strategies...\n", len(e.strats))
+	if state := e.kill.State(); state.Paused || state.DryRun || len(state.PausedCriteria) > 0 {
+		log.Printf("kill switch is active: %s", state)
+	}
+
+	e.runCycle(pods)
+}
+
+// clusterSuffix names the cluster of the Engine in log messages.
+func (e *Engine) clusterSuffix() string {
+	if e.cluster == "" {
+		return ""
+	}
+
+	return " " + e.cluster
+}
+
+// candidate is a pod selected for kicking by the strategy of a criteria
+type candidate struct {
+	strat    *strategy.Strategy
+	criteria conf.Criteria
+	pod      v1.Pod
+	reason   string
+}
+
+// runCycle evaluates every strategy against pods and merges their selections into a single kick list ordered by
+// criteria priority. Quarantined pods whose TTL expired are deleted first. Pods excluded by annotations or quarantined
+// are removed before any strategy sees them. Strategies paused by the kill switch or the api are not evaluated, so their
+// cool downs are not used up while paused. Pods selected by more than one criteria are resolved by the configured
+// overlap, and no pod is kicked more than once. The kick list is then checked against the global budget; pods that
+// exceed it are refused. A strategy only starts its cool down and other time windows once one of its pods is kicked,
+// so refused pods do not hold it back.
+func (e *Engine) runCycle(pods []v1.Pod) {
+	e.refreshNodes()
+	e.owners.reset()
+	e.expireQuarantined(pods, time.Now())
+	pods = e.filterAnnotated(pods)
+	e.evaluateTriggers(pods)
+	e.findOverlaps(pods)
+
+	// evaluations must not outlast the interval they are run in
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+
+	var candidates []candidate
+	for _, strat := range e.strats {
+		sc := strat.Criteria()
+		if e.kill.State().CriteriaPaused(sc.Name) {
+			log.Printf("skipping %s strategy: kicking is paused by the kill switch", sc.Name)
+			continue
+		}
+
+		if e.paused(sc.Name) {
+			log.Printf("skipping %s strategy: criteria is paused by the api", sc.Name)
+			continue
+		}
+
+		log.Printf("running %s strategy...", sc.Name)
+		res, err := strat.EvaluateContext(ctx, pods)
 		if err != nil {
-			log.Fatalln(err)
-		}
-
-		pods := podList.Items
-
-		log.Printf("There are %d pods in the cluster\n", len(pods))
-		log.Printf("Running %d strategies...\n", len(strats))
-
-		for _, strat := range strats {
-			sc := strat.Criteria()
-			log.Printf("running %s strategy...", sc.Name)
-			toKill := strat.Evaluate(pods)
-			for _, pod := range toKill {
-				log.Printf("kicking: %s...\n", pod.Name)
-				if dryRun {
-					continue
-				}
-
-				criteria := strat.Criteria()
-				fore := metav1.DeletePropagationForeground
-				opts := &metav1.DeleteOptions{
-					PropagationPolicy:  &fore,
-					GracePeriodSeconds: &criteria.GracePeriod,
-				}
-
-				if err := clientset.CoreV1().Pods(criteria.Namespace).Delete(pod.Name, opts); err != nil {
-					log.Printf("error kicking pod '%s': %s", pod.Name, err)
-				}
-			}
+			log.Printf("error running %s strategy, selecting nothing: %s", sc.Name, err)
+			continue
+		}
+
+		for _, pod := range res.Selected {
+			candidates = append(candidates, candidate{strat: strat, criteria: sc, pod: pod, reason: res.Reason(pod)})
+		}
+
+		log.Printf("completed %s strategy", sc.Name)
+	}
+
+	overlap := selectedOverlaps(e.configOrder, candidates)
+
+	attempted := map[string]int{}
+	failed := map[string]int{}
+	kicked := map[string]bool{}
+	for _, c := range candidates {
+		state := e.kill.State()
+		if state.CriteriaPaused(c.criteria.Name) {
+			e.refuse(c.criteria, c.pod, "kicking is paused by the kill switch")
+			continue
+		}
+
+		if e.paused(c.criteria.Name) {
+			e.refuse(c.criteria, c.pod, "criteria is paused by the api")
+			continue
+		}
+
+		if kicked[podKey(c.pod)] {
+			e.refuse(c.criteria, c.pod, "pod was already kicked this evaluation")
+			continue
+		}
+
+		if ok, reason := overlap.resolve(e.config.Overlap, c.strat, c.pod); !ok {
+			e.refuse(c.criteria, c.pod, reason)
+			continue
+		}
+
+		if ok, reason := e.budget.Allow(c.pod, time.Now()); !ok {
+			e.refuse(c.criteria, c.pod, reason)
+			continue
+		}
 
-			log.Printf("completed %s strategy", sc.Name)
+		attempted[c.criteria.Name]++
+		kicked[podKey(c.pod)] = true
+		if err := e.kick(c.criteria, c.pod, c.reason, e.dryRun || state.DryRun); err != nil {
+			failed[c.criteria.Name]++
+			continue
 		}
 
-		log.Printf("sleeping for %s", interval)
-		time.Sleep(interval)
+		c.strat.RecordKick(c.pod, time.Now())
+	}
+
+	for _, strat := range e.strats {
+		sc := strat.Criteria()
+		e.trackStuck(sc, attempted[sc.Name], failed[sc.Name])
+	}
+}
+
+// refuse records that a safeguard prevented the passed pod from being kicked.
+func (e *Engine) refuse(criteria conf.Criteria, pod v1.Pod, reason string) {
+	log.Printf("refusing to kick %s for %s: %s", pod.Name, criteria.Name, reason)
+	e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeRefused, errors.New(reason)))
+	e.notify(notify.EventSafeguardRefused, criteria, pod, reason, e.dryRun)
+}
+
+func (e *Engine) kick(criteria conf.Criteria, pod v1.Pod, reason string, dryRun bool) error {
+	log.Printf("kicking: %s...\n", pod.Name)
+	if dryRun {
+		e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeDryRun, nil))
+		e.notify(notify.EventKick, criteria, pod, reason, true)
+		return nil
+	}
+
+	if path, err := e.diagnostics[criteria.Name].Capture(pod, time.Now()); err != nil {
+		log.Printf("error capturing diagnostics of pod '%s', kicking anyway: %s", pod.Name, err)
+	} else if path != "" {
+		log.Printf("captured diagnostics of pod '%s' to %s", pod.Name, path)
+	}
+
+	if err := e.hooks.Run(pod, criteria.PreKick); err != nil {
+		if criteria.PreKick.OnFailure == conf.HookAbort {
+			err = fmt.Errorf("pre-kick hook failed: %s", err)
+			log.Printf("error kicking pod '%s': %s", pod.Name, err)
+			e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeFailed, err))
+			e.notify(notify.EventKickFailed, criteria, pod, err.Error(), false)
+			return err
+		}
+
+		log.Printf("pre-kick hook of pod '%s' failed, kicking anyway: %s", pod.Name, err)
+	}
+
+	if criteria.Action == conf.ActionQuarantine {
+		if err := e.quarantine(criteria, pod, time.Now()); err != nil {
+			log.Printf("error quarantining pod '%s': %s", pod.Name, err)
+			e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeFailed, err))
+			e.notify(notify.EventKickFailed, criteria, pod, err.Error(), false)
+			return err
+		}
+
+		e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeQuarantined, nil))
+		e.notify(notify.EventKick, criteria, pod, "quarantined", false)
+		return nil
+	}
+
+	fore := metav1.DeletePropagationForeground
+	opts := &metav1.DeleteOptions{
+		PropagationPolicy:  &fore,
+		GracePeriodSeconds: &criteria.GracePeriod,
+	}
+
+	// stuck terminating pods are force deleted; foreground propagation would add yet another finalizer to them
+	if criteria.Kind == conf.KindTerminating {
+		force := int64(0)
+		back := metav1.DeletePropagationBackground
+		opts.GracePeriodSeconds = &force
+		opts.PropagationPolicy = &back
+	}
+
+	if err := e.clientset.CoreV1().Pods(criteria.Namespace).Delete(pod.Name, opts); err != nil {
+		log.Printf("error kicking pod '%s': %s", pod.Name, err)
+		e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeFailed, err))
+		e.notify(notify.EventKickFailed, criteria, pod, err.Error(), false)
+		return err
+	}
+
+	e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeKicked, nil))
+	e.notify(notify.EventKick, criteria, pod, reason, false)
+	return nil
+}
+
+// trackStuck raises EventCriteriaStuck once a criteria has failed every kick for conf.Conf.StuckAfter consecutive
+// evaluations. Evaluations that select no pods neither advance nor reset the count.
+func (e *Engine) trackStuck(criteria conf.Criteria, attempted, failed int) {
+	if attempted <= 0 {
+		return
+	}
+
+	if failed < attempted {
+		e.failedCycles[criteria.Name] = 0
+		return
+	}
+
+	e.failedCycles[criteria.Name]++
+	if e.failedCycles[criteria.Name] == e.config.StuckAfter {
+		reason := fmt.Sprintf("every kick has failed for %d consecutive evaluations", e.config.StuckAfter)
+		log.Printf("criteria %s is stuck: %s", criteria.Name, reason)
+		e.notifier.Notify(notify.Event{
+			Kind:      notify.EventCriteriaStuck,
+			Cluster:   e.cluster,
+			Criteria:  criteria.Name,
+			Namespace: criteria.Namespace,
+			Reason:    reason,
+		})
+	}
+}
+
+func (e *Engine) notify(kind notify.EventKind, criteria conf.Criteria, pod v1.Pod, reason string, dryRun bool) {
+	e.notifier.Notify(notify.Event{
+		Kind:      kind,
+		Cluster:   e.cluster,
+		Criteria:  criteria.Name,
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Owner:     audit.Owner(pod),
+		Reason:    reason,
+		DryRun:    dryRun,
+	})
+}
+
+func (e *Engine) record(r audit.Record) {
+	r.Cluster = e.cluster
+	if err := e.audit.Write(r); err != nil {
+		log.Println(err)
 	}
 }
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/curlymon/kicker/pkg/api"
+	"github.com/curlymon/kicker/pkg/conf"
+)
+
+// Group runs one Engine per cluster of a conf.Conf. The engines share the audit log and notifier but are otherwise
+// independent, so a cluster that cannot be reached does not stop the others from being managed.
+type Group struct {
+	engines []*Engine
+	shared  *shared
+}
+
+// NewGroup builds a Group from the passed conf.Conf. A conf without conf.Conf.Clusters manages the cluster of its
+// kubeConf with a single Engine. Clusters that no criteria applies to are skipped.
+func NewGroup(config conf.Conf, dryRun bool) (*Group, error) {
+	s, err := openShared(config)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Group{shared: s}
+	if len(config.Clusters) <= 0 {
+		e, err := newEngine("", config, dryRun, s)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+
+		g.engines = append(g.engines, e)
+		return g, nil
+	}
+
+	for _, cl := range config.Clusters {
+		cc := config.ForCluster(cl)
+		if len(cc.Criteria) <= 0 {
+			log.Printf("no criteria applies to cluster %s, skipping it", cl.Name)
+			continue
+		}
+
+		e, err := newEngine(cl.Name, cc, dryRun, s)
+		if err != nil {
+			g.Close()
+			return nil, fmt.Errorf("Cluster %s: %s", cl.Name, err)
+		}
+
+		g.engines = append(g.engines, e)
+	}
+
+	if len(g.engines) <= 0 {
+		g.Close()
+		return nil, fmt.Errorf("no criteria applies to any cluster")
+	}
+
+	return g, nil
+}
+
+// Run runs every Engine of the Group. It never returns.
+func (g *Group) Run() {
+	for _, e := range g.engines[1:] {
+		go e.Run()
+	}
+
+	g.engines[0].Run()
+}
+
+// Close stops every Engine of the Group and closes the resources they share.
+func (g *Group) Close() error {
+	for _, e := range g.engines {
+		e.Close()
+	}
+
+	return g.shared.close()
+}
+
+// Ready implements api.Controller. The Group is ready once any Engine is, so that a cluster that cannot be reached
+// does not make the clusters that can be managed unready. The readiness of each cluster is reported by Status.
+func (g *Group) Ready() bool {
+	for _, e := range g.engines {
+		if e.Ready() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Status implements api.Controller. With more than one cluster the status of each is listed in api.Status.Clusters.
+func (g *Group) Status() api.Status {
+	if len(g.engines) == 1 {
+		return g.engines[0].Status()
+	}
+
+	status := api.Status{
+		Ready:    g.Ready(),
+		DryRun:   g.engines[0].dryRun,
+		Criteria: []api.CriteriaStatus{},
+		Clusters: make([]api.Status, 0, len(g.engines)),
+	}
+
+	for _, e := range g.engines {
+		status.Clusters = append(status.Clusters, e.Status())
+	}
+
+	return status
+}
+
+// Pause implements api.Controller. The criteria is paused in every cluster it applies to.
+func (g *Group) Pause(criteria string) error {
+	return g.setPaused(criteria, true)
+}
+
+// Resume implements api.Controller. The criteria is resumed in every cluster it applies to.
+func (g *Group) Resume(criteria string) error {
+	return g.setPaused(criteria, false)
+}
+
+// Trigger implements api.Controller. Every cluster is evaluated.
+func (g *Group) Trigger() {
+	for _, e := range g.engines {
+		e.Trigger()
+	}
+}
+
+func (g *Group) setPaused(criteria string, paused bool) error {
+	found := false
+	for _, e := range g.engines {
+		if !e.hasCriteria(criteria) {
+			continue
+		}
+
+		if err := e.setPaused(criteria, paused); err != nil {
+			return err
+		}
+
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("criteria '%s' does not exist", criteria)
+	}
+
+	return nil
+}
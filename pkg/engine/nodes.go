@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodeCache holds the labels of every node as of the last refresh.
+type nodeCache struct {
+	byName map[string]map[string]string
+}
+
+// labels implements strategy.NodeLabeler.
+func (n *nodeCache) labels(node string) map[string]string {
+	return n.byName[node]
+}
+
+// refreshNodes lists the cluster's nodes into the node cache if any criteria is topology aware. On error the previous
+// labels are kept.
+func (e *Engine) refreshNodes() {
+	var needed bool
+	for _, c := range e.config.Criteria {
+		if c.Topology.Enabled() {
+			needed = true
+			break
+		}
+	}
+
+	if !needed {
+		return
+	}
+
+	nodeList, err := e.clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		log.Printf("error listing nodes, keeping previous topology: %s", err)
+		return
+	}
+
+	byName := make(map[string]map[string]string, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		byName[node.Name] = node.Labels
+	}
+
+	e.nodes.byName = byName
+}
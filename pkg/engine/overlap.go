@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/metrics"
+	"github.com/curlymon/kicker/pkg/strategy"
+	"k8s.io/api/core/v1"
+)
+
+// sortByPriority orders strategies by descending conf.Criteria.Priority, keeping config order for equal priorities.
+// This is the order in which merged kicks are attempted.
+func sortByPriority(strats []*strategy.Strategy) {
+	sort.SliceStable(strats, func(i, j int) bool {
+		return strats[i].Criteria().Priority > strats[j].Criteria().Priority
+	})
+}
+
+// warnStaticOverlap logs every pair of criteria in the same namespace where one name is a prefix of the other, as
+// these can match the same pods.
+func warnStaticOverlap(cs []conf.Criteria) {
+	for i := range cs {
+		for j := i + 1; j < len(cs); j++ {
+			if cs[i].Namespace != cs[j].Namespace {
+				continue
+			}
+
+			if strings.HasPrefix(cs[i].Name, cs[j].Name) || strings.HasPrefix(cs[j].Name, cs[i].Name) {
+				log.Printf("criteria %s and %s in namespace %s may match the same pods", cs[i].Name, cs[j].Name, cs[i].Namespace)
+			}
+		}
+	}
+}
+
+func podKey(pod v1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// overlaps maps every pod selected by more than one strategy to the selecting strategies in config order.
+type overlaps map[string][]*strategy.Strategy
+
+// findOverlaps logs every pod within pods matched by more than one of the engine's strategies. The number of
+// overlapping pods of each criteria is kept for the status API and the overlapping pods metric.
+func (e *Engine) findOverlaps(pods []v1.Pod) {
+	counts := make(map[string]int, len(e.configOrder))
+	for _, pod := range pods {
+		var matched []string
+		for _, strat := range e.configOrder {
+			if strat.Matches(pod) {
+				matched = append(matched, strat.Criteria().Name)
+			}
+		}
+
+		if len(matched) <= 1 {
+			continue
+		}
+
+		for _, name := range matched {
+			counts[name]++
+		}
+
+		log.Printf("pod %s is matched by %d criteria: %s", podKey(pod), len(matched), strings.Join(matched, ", "))
+	}
+
+	for _, strat := range e.configOrder {
+		name := strat.Criteria().Name
+		metrics.OverlappingPods.WithLabelValues(e.cluster, name).Set(float64(counts[name]))
+	}
+
+	e.mu.Lock()
+	e.overlapping = counts
+	e.mu.Unlock()
+}
+
+// selectedOverlaps returns the overlaps between the strategies of candidates, ordered as in order. Only strategies that
+// selected a pod this evaluation take part in resolving it, so a criteria that matches the pod but is cooling down or
+// filtered it out does not keep the others from kicking it.
+func selectedOverlaps(order []*strategy.Strategy, candidates []candidate) overlaps {
+	selectedBy := map[string]map[*strategy.Strategy]bool{}
+	for _, c := range candidates {
+		key := podKey(c.pod)
+		if selectedBy[key] == nil {
+			selectedBy[key] = map[*strategy.Strategy]bool{}
+		}
+
+		selectedBy[key][c.strat] = true
+	}
+
+	out := overlaps{}
+	for key, strats := range selectedBy {
+		if len(strats) <= 1 {
+			continue
+		}
+
+		for _, strat := range order {
+			if strats[strat] {
+				out[key] = append(out[key], strat)
+			}
+		}
+	}
+
+	return out
+}
+
+// resolve reports whether strat may kick pod under the configured conf.Overlap, returning a reason when it may not.
+func (o overlaps) resolve(mode conf.Overlap, strat *strategy.Strategy, pod v1.Pod) (bool, string) {
+	selected, ok := o[podKey(pod)]
+	if !ok {
+		return true, ""
+	}
+
+	var winner *strategy.Strategy
+	switch mode {
+	case conf.OverlapRefuse:
+		return false, fmt.Sprintf("pod is selected by %d criteria and overlap is %s", len(selected), mode)
+	case conf.OverlapPriority:
+		winner = selected[0]
+		for _, m := range selected[1:] {
+			if m.Criteria().Priority > winner.Criteria().Priority {
+				winner = m
+			}
+		}
+	default:
+		winner = selected[0]
+	}
+
+	if winner != strat {
+		return false, fmt.Sprintf("pod is owned by criteria %s under %s overlap", winner.Criteria().Name, mode)
+	}
+
+	return true, ""
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/strategy"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	strategy.RegisterEvaluatorConstructor("testOverlap", func(c conf.Criteria) strategy.Evaluator {
+		return func(pods []v1.Pod) []v1.Pod {
+			return pods
+		}
+	})
+}
+
+func overlapStrategy(t *testing.T, name string, priority int64) *strategy.Strategy {
+	strat, err := strategy.NewStrategy(conf.Criteria{
+		Name:      name,
+		Namespace: "default",
+		Kind:      conf.KindRunning,
+		Strategy:  "testOverlap",
+		Priority:  priority,
+	}, strategy.Lookups{})
+	if err != nil {
+		t.Fatalf("NewStrategy() error = %s", err)
+	}
+
+	return strat
+}
+
+func TestResolveSelectedOverlaps(t *testing.T) {
+	web := overlapStrategy(t, "web", 0)
+	webAPI := overlapStrategy(t, "web-api", 10)
+	order := []*strategy.Strategy{web, webAPI}
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-api-1"}}
+
+	tests := []struct {
+		name     string
+		mode     conf.Overlap
+		selected []*strategy.Strategy
+		strat    *strategy.Strategy
+		allowed  bool
+	}{
+		{
+			name:     "first owned by the first selecting criteria",
+			mode:     conf.OverlapFirst,
+			selected: []*strategy.Strategy{web, webAPI},
+			strat:    webAPI,
+		},
+		{
+			name:     "first kicked by the first selecting criteria",
+			mode:     conf.OverlapFirst,
+			selected: []*strategy.Strategy{webAPI, web},
+			strat:    web,
+			allowed:  true,
+		},
+		{
+			name:     "first not owned by a matching criteria that did not select",
+			mode:     conf.OverlapFirst,
+			selected: []*strategy.Strategy{webAPI},
+			strat:    webAPI,
+			allowed:  true,
+		},
+		{
+			name:     "priority owned by the highest priority",
+			mode:     conf.OverlapPriority,
+			selected: []*strategy.Strategy{web, webAPI},
+			strat:    web,
+		},
+		{
+			name:     "priority not owned by a matching criteria that did not select",
+			mode:     conf.OverlapPriority,
+			selected: []*strategy.Strategy{web},
+			strat:    web,
+			allowed:  true,
+		},
+		{
+			name:     "refuse when selected twice",
+			mode:     conf.OverlapRefuse,
+			selected: []*strategy.Strategy{web, webAPI},
+			strat:    webAPI,
+		},
+		{
+			name:     "refuse allows a single selecting criteria",
+			mode:     conf.OverlapRefuse,
+			selected: []*strategy.Strategy{webAPI},
+			strat:    webAPI,
+			allowed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var candidates []candidate
+			for _, strat := range tt.selected {
+				candidates = append(candidates, candidate{strat: strat, criteria: strat.Criteria(), pod: pod})
+			}
+
+			allowed, reason := selectedOverlaps(order, candidates).resolve(tt.mode, tt.strat, pod)
+			if allowed != tt.allowed {
+				t.Errorf("resolve() = %t, '%s', want %t", allowed, reason, tt.allowed)
+			}
+
+			if !allowed && reason == "" {
+				t.Errorf("resolve() refused without a reason")
+			}
+		})
+	}
+}
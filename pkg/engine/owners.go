@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ownerCache retrieves and caches the owners of pods. It is reset at the start of every evaluation so that owners are
+// read at most once per evaluation. It is safe for concurrent use.
+type ownerCache struct {
+	clientset kubernetes.Interface
+
+	mu   sync.Mutex
+	objs map[string]metav1.Object
+}
+
+func newOwnerCache(clientset kubernetes.Interface) *ownerCache {
+	return &ownerCache{
+		clientset: clientset,
+		objs:      map[string]metav1.Object{},
+	}
+}
+
+// reset drops all cached owners.
+func (o *ownerCache) reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.objs = map[string]metav1.Object{}
+}
+
+// get retrieves the object referenced by ref, caching the result. Kinds that are not known return nil.
+func (o *ownerCache) get(namespace string, ref *metav1.OwnerReference) (metav1.Object, error) {
+	key := ref.Kind + "/" + namespace + "/" + ref.Name
+	o.mu.Lock()
+	obj, ok := o.objs[key]
+	o.mu.Unlock()
+	if ok {
+		return obj, nil
+	}
+
+	obj, err := o.fetch(namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	o.objs[key] = obj
+	o.mu.Unlock()
+	return obj, nil
+}
+
+func (o *ownerCache) fetch(namespace string, ref *metav1.OwnerReference) (metav1.Object, error) {
+	opts := metav1.GetOptions{}
+	switch ref.Kind {
+	case "ReplicaSet":
+		return o.clientset.AppsV1().ReplicaSets(namespace).Get(ref.Name, opts)
+	case "Deployment":
+		return o.clientset.AppsV1().Deployments(namespace).Get(ref.Name, opts)
+	case "StatefulSet":
+		return o.clientset.AppsV1().StatefulSets(namespace).Get(ref.Name, opts)
+	case "DaemonSet":
+		return o.clientset.AppsV1().DaemonSets(namespace).Get(ref.Name, opts)
+	case "Job":
+		return o.clientset.BatchV1().Jobs(namespace).Get(ref.Name, opts)
+	case "ReplicationController":
+		return o.clientset.CoreV1().ReplicationControllers(namespace).Get(ref.Name, opts)
+	}
+
+	return nil, nil
+}
+
+// replicas implements strategy.ReplicaCounter. It walks up the owner chain of the pod and returns the top most owner
+// that defines a desired replica count, so that pods of a Deployment resolve to the Deployment rather than the
+// ReplicaSet.
+func (o *ownerCache) replicas(pod v1.Pod) (string, int64, bool) {
+	var (
+		owner string
+		count int64
+		found bool
+	)
+
+	ref := metav1.GetControllerOf(&pod)
+	for depth := 0; ref != nil && depth < maxOwnerDepth; depth++ {
+		obj, err := o.get(pod.Namespace, ref)
+		if err != nil || obj == nil {
+			break
+		}
+
+		if n, ok := desiredReplicas(obj); ok {
+			owner, count, found = ref.Kind+"/"+pod.Namespace+"/"+ref.Name, n, true
+		}
+
+		ref = metav1.GetControllerOf(obj)
+	}
+
+	return owner, count, found
+}
+
+// desiredReplicas returns the desired replica count of the passed owner object, if it defines one.
+func desiredReplicas(obj metav1.Object) (int64, bool) {
+	var replicas *int32
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		replicas = o.Spec.Replicas
+	case *appsv1.ReplicaSet:
+		replicas = o.Spec.Replicas
+	case *appsv1.StatefulSet:
+		replicas = o.Spec.Replicas
+	case *v1.ReplicationController:
+		replicas = o.Spec.Replicas
+	default:
+		return 0, false
+	}
+
+	if replicas == nil {
+		return 1, true
+	}
+
+	return int64(*replicas), true
+}
@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/audit"
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/notify"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// LabelQuarantined is set to "true" on quarantined pods. Quarantined pods are never kicked again, except to delete
+	// them once their TTL expires.
+	LabelQuarantined = "kicker.io/quarantined"
+
+	// AnnotationQuarantineReason holds why a pod was quarantined.
+	AnnotationQuarantineReason = "kicker.io/quarantine-reason"
+
+	// AnnotationQuarantineExpires holds the RFC3339 timestamp after which a quarantined pod is deleted. Pods without it
+	// are kept until removed by hand.
+	AnnotationQuarantineExpires = "kicker.io/quarantine-expires"
+)
+
+// quarantine takes the pod out of rotation by removing every label used by its controller's selector or by a Service
+// selecting it, and marks it with LabelQuarantined. Its controller then releases it and creates a replacement.
+func (e *Engine) quarantine(criteria conf.Criteria, pod v1.Pod, now time.Time) error {
+	keys, err := e.selectedBy(pod)
+	if err != nil {
+		return err
+	}
+
+	if ref := metav1.GetControllerOf(&pod); ref != nil && ref.Kind == "StatefulSet" {
+		log.Printf("pod '%s' belongs to StatefulSet %s which cannot replace it until it is deleted", pod.Name, ref.Name)
+	}
+
+	podLabels := map[string]interface{}{LabelQuarantined: "true"}
+	for key := range keys {
+		podLabels[key] = nil
+	}
+
+	annotations := map[string]interface{}{
+		AnnotationQuarantineReason: fmt.Sprintf("kicked by criteria %s at %s", criteria.Name, now.UTC().Format(time.RFC3339)),
+	}
+
+	if criteria.Quarantine.TTL > 0 {
+		expires := now.Add(time.Duration(criteria.Quarantine.TTL) * time.Second)
+		annotations[AnnotationQuarantineExpires] = expires.UTC().Format(time.RFC3339)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      podLabels,
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = e.clientset.CoreV1().Pods(pod.Namespace).Patch(pod.Name, types.MergePatchType, patch)
+	return err
+}
+
+// selectedBy returns the label keys the pod's controller and the Services selecting it select on.
+func (e *Engine) selectedBy(pod v1.Pod) (map[string]bool, error) {
+	keys := map[string]bool{}
+	if ref := metav1.GetControllerOf(&pod); ref != nil {
+		owner, err := e.owners.get(pod.Namespace, ref)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving owner %s/%s: %s", ref.Kind, ref.Name, err)
+		}
+
+		for key := range selectorKeys(owner) {
+			keys[key] = true
+		}
+	}
+
+	services, err := e.clientset.CoreV1().Services(pod.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing services: %s", err)
+	}
+
+	for _, svc := range services.Items {
+		if len(svc.Spec.Selector) <= 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		for key := range svc.Spec.Selector {
+			keys[key] = true
+		}
+	}
+
+	return keys, nil
+}
+
+// selectorKeys returns the label keys selected on by the passed controller object.
+func selectorKeys(obj metav1.Object) map[string]bool {
+	var selector *metav1.LabelSelector
+	switch o := obj.(type) {
+	case *appsv1.ReplicaSet:
+		selector = o.Spec.Selector
+	case *appsv1.Deployment:
+		selector = o.Spec.Selector
+	case *appsv1.StatefulSet:
+		selector = o.Spec.Selector
+	case *appsv1.DaemonSet:
+		selector = o.Spec.Selector
+	case *batchv1.Job:
+		selector = o.Spec.Selector
+	case *v1.ReplicationController:
+		selector = &metav1.LabelSelector{MatchLabels: o.Spec.Selector}
+	}
+
+	keys := map[string]bool{}
+	if selector == nil {
+		return keys
+	}
+
+	for key := range selector.MatchLabels {
+		keys[key] = true
+	}
+
+	for _, req := range selector.MatchExpressions {
+		keys[req.Key] = true
+	}
+
+	return keys
+}
+
+// expireQuarantined deletes quarantined pods whose TTL has expired. A pod is only deleted on behalf of the first
+// criteria, in priority order, that quarantines pods of its namespace, and only as that criteria would kick it: not
+// while the criteria is paused by the kill switch or the api, and not while dry run is enabled. Deletions are recorded
+// and notified like kicks.
+func (e *Engine) expireQuarantined(pods []v1.Pod, now time.Time) {
+	state := e.kill.State()
+	for _, pod := range pods {
+		if pod.Labels[LabelQuarantined] != "true" || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		value, ok := pod.Annotations[AnnotationQuarantineExpires]
+		if !ok {
+			continue
+		}
+
+		expires, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Printf("quarantined pod %s has an invalid %s '%s': %s", podKey(pod), AnnotationQuarantineExpires, value, err)
+			continue
+		}
+
+		if now.Before(expires) {
+			continue
+		}
+
+		criteria, ok := e.quarantinedBy(pod)
+		if !ok {
+			continue
+		}
+
+		if state.CriteriaPaused(criteria.Name) {
+			log.Printf("quarantine of pod %s expired, not deleting: %s is paused by the kill switch", podKey(pod), criteria.Name)
+			continue
+		}
+
+		if e.paused(criteria.Name) {
+			log.Printf("quarantine of pod %s expired, not deleting: %s is paused by the api", podKey(pod), criteria.Name)
+			continue
+		}
+
+		e.deleteQuarantined(criteria, pod, e.dryRun || state.DryRun)
+	}
+}
+
+// quarantinedBy returns the criteria on whose behalf the passed quarantined pod is deleted once its TTL expires.
+func (e *Engine) quarantinedBy(pod v1.Pod) (conf.Criteria, bool) {
+	for _, strat := range e.strats {
+		c := strat.Criteria()
+		if c.Action == conf.ActionQuarantine && c.Namespace == pod.Namespace {
+			return c, true
+		}
+	}
+
+	return conf.Criteria{}, false
+}
+
+func (e *Engine) deleteQuarantined(criteria conf.Criteria, pod v1.Pod, dryRun bool) {
+	reason := "quarantine expired"
+	log.Printf("quarantine of pod %s expired, deleting...", podKey(pod))
+	if dryRun {
+		e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeDryRun, nil))
+		e.notify(notify.EventKick, criteria, pod, reason, true)
+		return
+	}
+
+	if err := e.clientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+		log.Printf("error deleting quarantined pod %s: %s", podKey(pod), err)
+		e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeFailed, err))
+		e.notify(notify.EventKickFailed, criteria, pod, err.Error(), false)
+		return
+	}
+
+	e.record(audit.NewRecord(criteria.Name, pod, audit.OutcomeKicked, nil))
+	e.notify(notify.EventKick, criteria, pod, reason, false)
+}
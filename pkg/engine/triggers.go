@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/curlymon/kicker/pkg/client"
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/trigger"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// triggerState evaluates the triggers of every criteria once per evaluation and holds why they fired for each pod.
+type triggerState struct {
+	sources  trigger.Sources
+	triggers map[string]trigger.Trigger
+	fired    map[string]map[string]string
+}
+
+// newTriggerState builds the triggers of every criteria that has any, connecting to the data sources they need.
+func newTriggerState(config conf.Conf, clientset kubernetes.Interface) (*triggerState, error) {
+	t := &triggerState{
+		sources: trigger.Sources{
+			Logs: trigger.NewClientLogReader(clientset),
+		},
+		triggers: map[string]trigger.Trigger{},
+		fired:    map[string]map[string]string{},
+	}
+
+	for _, c := range config.Criteria {
+		if c.Triggers.Resources != nil && t.sources.Metrics == nil {
+			metrics, err := client.NewMetrics(config)
+			if err != nil {
+				return nil, err
+			}
+
+			t.sources.Metrics = trigger.NewMetricsCache(metrics)
+		}
+	}
+
+	if config.Prometheus.URL != "" {
+		t.sources.Prometheus = trigger.NewHTTPQuerier(config.Prometheus)
+	}
+
+	for _, c := range config.Criteria {
+		if c.Triggers.Enabled() {
+			t.triggers[c.Name] = trigger.New(c.Triggers, t.sources)
+		}
+	}
+
+	return t, nil
+}
+
+// triggered implements strategy.TriggerLookup.
+func (t *triggerState) triggered(criteria string, pod v1.Pod) (string, bool) {
+	reason, fired := t.fired[criteria][trigger.Key(pod)]
+	return reason, fired
+}
+
+// evaluateTriggers evaluates the triggers of every criteria against the pods it matches.
+func (e *Engine) evaluateTriggers(pods []v1.Pod) {
+	t := e.triggers
+	t.sources.Metrics.Reset()
+	t.fired = make(map[string]map[string]string, len(t.triggers))
+	now := time.Now()
+	for _, strat := range e.configOrder {
+		name := strat.Criteria().Name
+		trig, ok := t.triggers[name]
+		if !ok {
+			continue
+		}
+
+		matched := make([]v1.Pod, 0, len(pods))
+		for _, pod := range pods {
+			if strat.Matches(pod) {
+				matched = append(matched, pod)
+			}
+		}
+
+		t.fired[name] = trig.Fired(matched, now)
+	}
+}
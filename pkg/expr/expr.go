@@ -0,0 +1,170 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"k8s.io/api/core/v1"
+)
+
+// env declares the variables available to expressions. The pod is exposed as its JSON representation, so fields use
+// the names of the Kubernetes API, e.g. pod.metadata.labels or pod.status.containerStatuses. CEL types pod as a map;
+// Compile checks the fields selected on it against v1.Pod instead.
+var env *cel.Env
+
+func init() {
+	var err error
+	env, err = cel.NewEnv(
+		cel.Declarations(
+			decls.NewVar("pod", decls.NewMapType(decls.String, decls.Dyn)),
+		),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Program is a compiled CEL expression over a pod.
+type Program struct {
+	expr string
+	prg  cel.Program
+}
+
+// Compile parses and type checks the passed CEL expression. The expression must evaluate to a bool.
+func Compile(expr string) (*Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, fmt.Errorf("expression '%s' is invalid: %s", expr, iss.Err())
+	}
+
+	if t := ast.ResultType(); t.GetPrimitive() != exprpb.Type_BOOL && t.GetDyn() == nil {
+		return nil, fmt.Errorf("expression '%s' must evaluate to a bool", expr)
+	}
+
+	if err := checkFields(ast.Expr()); err != nil {
+		return nil, fmt.Errorf("expression '%s' is invalid: %s", expr, err)
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("expression '%s' is invalid: %s", expr, err)
+	}
+
+	return &Program{expr: expr, prg: prg}, nil
+}
+
+// Matches evaluates the expression against the passed pod. Errors, e.g. selecting a field the pod does not have, are
+// logged and do not match; use has() to test for optional fields.
+func (p *Program) Matches(pod v1.Pod) bool {
+	obj, err := pods.get(pod)
+	if err != nil {
+		log.Printf("error converting pod %s/%s for expression '%s': %s", pod.Namespace, pod.Name, p.expr, err)
+		return false
+	}
+
+	out, _, err := p.prg.Eval(map[string]interface{}{"pod": obj})
+	if err != nil {
+		log.Printf("error evaluating expression '%s' for pod %s/%s: %s", p.expr, pod.Namespace, pod.Name, err)
+		return false
+	}
+
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
+
+// pods holds the pods converted for evaluation, so each version of a pod is converted once however many expressions
+// and cycles evaluate it.
+var pods = &conversions{
+	current:  map[string]map[string]interface{}{},
+	previous: map[string]map[string]interface{}{},
+}
+
+// convert converts a pod for evaluation. It is replaced in tests to count conversions.
+var convert = toMap
+
+// retain is how long a converted pod is kept without being evaluated.
+const retain = time.Minute
+
+// conversions caches converted pods by UID and resourceVersion, which changes whenever the pod does. Pods evaluated
+// within the last retain period are kept: entries are moved to previous when it passes and dropped when it passes
+// again without them being evaluated. Converted pods are shared and must not be modified.
+type conversions struct {
+	mu       sync.Mutex
+	rotated  time.Time
+	current  map[string]map[string]interface{}
+	previous map[string]map[string]interface{}
+}
+
+// get returns the passed pod converted for evaluation. Pods without a UID or resourceVersion, e.g. built rather than
+// listed, are converted every time as they may have been changed.
+func (c *conversions) get(pod v1.Pod) (map[string]interface{}, error) {
+	if pod.UID == "" || pod.ResourceVersion == "" {
+		return convert(pod)
+	}
+
+	key := string(pod.UID) + "/" + pod.ResourceVersion
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now := time.Now(); now.Sub(c.rotated) > retain {
+		c.previous, c.current = c.current, map[string]map[string]interface{}{}
+		c.rotated = now
+	}
+
+	if obj, ok := c.current[key]; ok {
+		return obj, nil
+	}
+
+	obj, ok := c.previous[key]
+	if !ok {
+		var err error
+		if obj, err = convert(pod); err != nil {
+			return nil, err
+		}
+	}
+
+	c.current[key] = obj
+	return obj, nil
+}
+
+// toMap converts the pod to its JSON representation. Whole numbers are converted to int64 so that they compare with
+// integer literals, e.g. c.restartCount > 3.
+func toMap(pod v1.Pod) (map[string]interface{}, error) {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+
+	return integers(obj).(map[string]interface{}), nil
+}
+
+func integers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = integers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = integers(e)
+		}
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < math.MaxInt64 {
+			return int64(t)
+		}
+	}
+
+	return v
+}
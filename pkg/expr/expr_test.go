@@ -0,0 +1,145 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{name: "restart count", expr: "pod.status.containerStatuses.exists(c, c.restartCount > 3)"},
+		{name: "has", expr: `!has(pod.metadata.annotations) || !("example.com/critical" in pod.metadata.annotations)`},
+		{name: "label index", expr: `pod.metadata.labels["app"] == "web"`},
+		{name: "label select", expr: `pod.metadata.labels.app == "web"`},
+		{name: "list index", expr: `pod.spec.containers[0].name == "app"`},
+		{name: "inline type meta", expr: `pod.kind == "Pod"`},
+		{name: "nested comprehension", expr: `pod.spec.containers.all(c, c.ports.all(p, p.containerPort > 1024))`},
+		{name: "shadowed pod", expr: `pod.spec.containers.exists(pod, pod.image == "web")`},
+		{name: "quantity", expr: `pod.spec.containers.exists(c, c.resources.limits.memory == "1Gi")`},
+		{name: "unknown top level field", expr: `pod.metdata.name == "web"`, err: "pod has no field metdata"},
+		{name: "unknown nested field", expr: `pod.status.phse == "Running"`, err: "pod.status has no field phse"},
+		{name: "unknown has field", expr: `has(pod.spec.nodeNmae)`, err: "pod.spec has no field nodeNmae"},
+		{name: "unknown list element field", expr: `pod.spec.containers[0].imagee == "web"`, err: "pod.spec.containers[] has no field imagee"},
+		{name: "unknown comprehension field", expr: `pod.spec.containers.exists(c, c.nmae == "app")`, err: "pod.spec.containers[] has no field nmae"},
+		{name: "field of a string", expr: `pod.metadata.creationTimestamp.seconds > 0`, err: "pod.metadata.creationTimestamp is not an object"},
+		{name: "not a bool", expr: `size(pod.spec.containers)`, err: "must evaluate to a bool"},
+		{name: "syntax", expr: `pod.metadata.name ==`, err: "is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("Compile() error = %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Compile() error = %v, want it to contain %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{Name: "app", RestartCount: 4}},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: `pod.metadata.labels.app == "web"`, want: true},
+		{expr: `pod.metadata.labels.app == "api"`, want: false},
+		{expr: `pod.status.containerStatuses.exists(c, c.restartCount > 3)`, want: true},
+		{expr: `pod.status.containerStatuses.exists(c, c.restartCount > 4)`, want: false},
+		{expr: `has(pod.metadata.annotations)`, want: false},
+		// selecting a field the pod has not set is an error at evaluation, which does not match
+		{expr: `pod.metadata.annotations.owner == "me"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			prg, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile() error = %s", err)
+			}
+
+			if got := prg.Matches(pod); got != tt.want {
+				t.Errorf("Matches() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesConvertsEachPodVersionOnce(t *testing.T) {
+	var conversions int
+	convert = func(pod v1.Pod) (map[string]interface{}, error) {
+		conversions++
+		return toMap(pod)
+	}
+	defer func() { convert = toMap }()
+
+	web, err := Compile(`pod.metadata.labels.app == "web"`)
+	if err != nil {
+		t.Fatalf("Compile() error = %s", err)
+	}
+
+	restarted, err := Compile(`pod.status.containerStatuses.exists(c, c.restartCount > 3)`)
+	if err != nil {
+		t.Fatalf("Compile() error = %s", err)
+	}
+
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "web-1",
+		Namespace:       "default",
+		UID:             "web-1-uid",
+		ResourceVersion: "1",
+		Labels:          map[string]string{"app": "web"},
+	}}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		version     string
+		want        bool
+		conversions int
+	}{
+		{name: "first evaluation", labels: map[string]string{"app": "web"}, version: "1", want: true, conversions: 1},
+		{name: "same version", labels: map[string]string{"app": "web"}, version: "1", want: true, conversions: 1},
+		{name: "new version", labels: map[string]string{"app": "api"}, version: "2", want: false, conversions: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod.Labels = tt.labels
+			pod.ResourceVersion = tt.version
+
+			if got := web.Matches(pod); got != tt.want {
+				t.Errorf("Matches() = %t, want %t", got, tt.want)
+			}
+
+			restarted.Matches(pod)
+			if conversions != tt.conversions {
+				t.Errorf("converted %d times, want %d", conversions, tt.conversions)
+			}
+		})
+	}
+}
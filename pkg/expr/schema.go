@@ -0,0 +1,172 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"k8s.io/api/core/v1"
+)
+
+// podType is the schema fields selected on pod are checked against
+var podType = reflect.TypeOf(v1.Pod{})
+
+// checkFields returns an error for the first field selected on pod, or on a variable ranging over one of its lists,
+// that a v1.Pod does not have, e.g. a typo such as pod.metdata.labels. Map keys, e.g. label names, are not checked.
+func checkFields(e *exprpb.Expr) error {
+	c := &fieldChecker{}
+	c.walk(e, map[string]field{"pod": {typ: podType, path: "pod"}})
+	return c.err
+}
+
+// field is the type of a value selected from pod, and the path it was selected by.
+type field struct {
+	typ  reflect.Type
+	path string
+}
+
+type fieldChecker struct {
+	err error
+}
+
+// walk checks every field selected in e and returns the type of e if it is a field of pod, or nil if it is not or its
+// type cannot be known.
+func (c *fieldChecker) walk(e *exprpb.Expr, scope map[string]field) *field {
+	if e == nil || c.err != nil {
+		return nil
+	}
+
+	switch k := e.ExprKind.(type) {
+	case *exprpb.Expr_IdentExpr:
+		if f, ok := scope[k.IdentExpr.Name]; ok {
+			return &f
+		}
+	case *exprpb.Expr_SelectExpr:
+		operand := c.walk(k.SelectExpr.Operand, scope)
+		if operand == nil {
+			return nil
+		}
+
+		return c.selectField(*operand, k.SelectExpr.Field)
+	case *exprpb.Expr_CallExpr:
+		c.walk(k.CallExpr.Target, scope)
+		var args []*field
+		for _, arg := range k.CallExpr.Args {
+			args = append(args, c.walk(arg, scope))
+		}
+
+		if k.CallExpr.Function == "_[_]" && len(args) == 2 && args[0] != nil {
+			return elem(*args[0])
+		}
+	case *exprpb.Expr_ListExpr:
+		for _, el := range k.ListExpr.Elements {
+			c.walk(el, scope)
+		}
+	case *exprpb.Expr_StructExpr:
+		for _, entry := range k.StructExpr.Entries {
+			c.walk(entry.GetMapKey(), scope)
+			c.walk(entry.Value, scope)
+		}
+	case *exprpb.Expr_ComprehensionExpr:
+		comp := k.ComprehensionExpr
+		iterRange := c.walk(comp.IterRange, scope)
+		c.walk(comp.AccuInit, scope)
+
+		inner := make(map[string]field, len(scope)+1)
+		for name, f := range scope {
+			inner[name] = f
+		}
+
+		delete(inner, comp.AccuVar)
+		delete(inner, comp.IterVar)
+		if iterRange != nil && iterRange.typ.Kind() == reflect.Slice {
+			if el := elem(*iterRange); el != nil {
+				inner[comp.IterVar] = *el
+			}
+		}
+
+		c.walk(comp.LoopCondition, inner)
+		c.walk(comp.LoopStep, inner)
+		c.walk(comp.Result, inner)
+	}
+
+	return nil
+}
+
+// selectField returns the named field of f, recording an error if f is an object without it.
+func (c *fieldChecker) selectField(f field, name string) *field {
+	path := f.path + "." + name
+	switch f.typ.Kind() {
+	case reflect.Map:
+		return &field{typ: deref(f.typ.Elem()), path: path}
+	case reflect.Struct:
+		if !isObject(f.typ) {
+			c.err = fmt.Errorf("%s is not an object, it has no field %s", f.path, name)
+			return nil
+		}
+
+		if typ, ok := jsonField(f.typ, name); ok {
+			return &field{typ: typ, path: path}
+		}
+
+		c.err = fmt.Errorf("%s has no field %s", f.path, name)
+	}
+
+	return nil
+}
+
+// elem returns the type of the elements of a list or map field.
+func elem(f field) *field {
+	switch f.typ.Kind() {
+	case reflect.Slice, reflect.Map:
+		return &field{typ: deref(f.typ.Elem()), path: f.path + "[]"}
+	}
+
+	return nil
+}
+
+// jsonField returns the type of the field of struct t with the passed JSON name, looking into inlined structs.
+func jsonField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := strings.Split(sf.Tag.Get("json"), ",")
+		if tag[0] == "-" || sf.PkgPath != "" {
+			continue
+		}
+
+		if tag[0] == "" && sf.Anonymous {
+			if typ, ok := jsonField(deref(sf.Type), name); ok {
+				return typ, true
+			}
+			continue
+		}
+
+		fieldName := tag[0]
+		if fieldName == "" {
+			fieldName = sf.Name
+		}
+
+		if fieldName == name {
+			return deref(sf.Type), true
+		}
+	}
+
+	return nil, false
+}
+
+// isObject reports whether values of struct t are JSON objects. Types such as metav1.Time or resource.Quantity marshal
+// to strings.
+func isObject(t reflect.Type) bool {
+	return !reflect.PtrTo(t).Implements(marshalerType) && !t.Implements(marshalerType)
+}
+
+var marshalerType = reflect.TypeOf((*interface{ MarshalJSON() ([]byte, error) })(nil)).Elem()
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
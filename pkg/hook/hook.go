@@ -0,0 +1,170 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/client"
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// maxOutput is the number of bytes of a hook's output included in its error
+const maxOutput = 1024
+
+// Executor runs conf.Hooks inside pods through the pods/exec subresource.
+type Executor struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+// New builds an Executor connecting to the cluster defined by the passed conf.Conf.
+func New(c conf.Conf) (*Executor, error) {
+	config, err := client.RestConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Executor{
+		config:    config,
+		clientset: clientset,
+	}, nil
+}
+
+// Run executes the passed hook in the pod and waits for it to exit. It returns an error holding the tail of the hook's
+// output if the command fails, or if it does not exit within the hook's timeout. The connection of a timed out command
+// is closed, but the command is abandoned rather than killed; it keeps running in the container until it exits. A nil
+// hook does nothing.
+func (e *Executor) Run(pod v1.Pod, h *conf.Hook) error {
+	if h == nil {
+		return nil
+	}
+
+	container := h.Container
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: container,
+			Command:   h.Command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	transport, upgrader, err := spdy.RoundTripperFor(e.config)
+	if err != nil {
+		return err
+	}
+
+	conn := &connTracker{upgrader: upgrader}
+	exec, err := remotecommand.NewSPDYExecutorForTransports(transport, conn, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	out := &lockedBuffer{}
+	done := make(chan error, 1)
+	go func() {
+		done <- exec.Stream(remotecommand.StreamOptions{
+			Stdout: out,
+			Stderr: out,
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("command %s in container %s failed: %s: %s", strings.Join(h.Command, " "), container, err, tail(out.Bytes()))
+		}
+
+		return nil
+	case <-time.After(time.Duration(h.Timeout) * time.Second):
+		// closing the connection ends the stream so that neither it nor its goroutine outlive the hook
+		conn.Close()
+		return fmt.Errorf("command %s in container %s did not exit within %ds", strings.Join(h.Command, " "), container, h.Timeout)
+	}
+}
+
+// connTracker wraps a spdy.Upgrader to keep hold of the connection it creates, so that the connection can be closed
+// when the stream using it is abandoned.
+type connTracker struct {
+	upgrader spdy.Upgrader
+
+	mu     sync.Mutex
+	conn   httpstream.Connection
+	closed bool
+}
+
+// NewConnection implements spdy.Upgrader. A connection created after Close is closed right away.
+func (c *connTracker) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := c.upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+	if c.closed {
+		conn.Close()
+	}
+
+	return conn, nil
+}
+
+// Close closes the tracked connection, if any.
+func (c *connTracker) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+// lockedBuffer lets stdout and stderr be written to the same buffer concurrently.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+func (l *lockedBuffer) Bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]byte(nil), l.buf.Bytes()...)
+}
+
+func tail(b []byte) string {
+	if len(b) > maxOutput {
+		b = b[len(b)-maxOutput:]
+	}
+
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,210 @@
+package killswitch
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/metrics"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// KeyPause pauses all kicking when set to "true"
+	KeyPause = "pause"
+	// KeyPauseCriteria pauses kicking by a comma separated list of criteria names
+	KeyPauseCriteria = "pauseCriteria"
+	// KeyDryRun forces dry run mode when set to "true"
+	KeyDryRun = "dryRun"
+)
+
+// retryInterval is how long to wait before re-establishing a failed watch
+const retryInterval = 10 * time.Second
+
+// State is the pause state read from the kill switch ConfigMap.
+type State struct {
+	// Paused pauses all kicking
+	Paused bool `json:"paused"`
+
+	// PausedCriteria holds the names of paused criteria
+	PausedCriteria []string `json:"pausedCriteria,omitempty"`
+
+	// DryRun forces dry run mode
+	DryRun bool `json:"dryRun"`
+}
+
+// CriteriaPaused reports whether the named criteria is paused, either directly or because all kicking is paused.
+func (s State) CriteriaPaused(name string) bool {
+	if s.Paused {
+		return true
+	}
+
+	for _, c := range s.PausedCriteria {
+		if c == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s State) String() string {
+	return fmt.Sprintf("paused=%t pausedCriteria=[%s] dryRun=%t", s.Paused, strings.Join(s.PausedCriteria, ","), s.DryRun)
+}
+
+// parse builds a State from the data of a ConfigMap. A nil ConfigMap yields the zero State.
+func parse(cm *v1.ConfigMap) State {
+	if cm == nil {
+		return State{}
+	}
+
+	s := State{
+		Paused: cm.Data[KeyPause] == "true",
+		DryRun: cm.Data[KeyDryRun] == "true",
+	}
+
+	for _, name := range strings.Split(cm.Data[KeyPauseCriteria], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			s.PausedCriteria = append(s.PausedCriteria, name)
+		}
+	}
+
+	sort.Strings(s.PausedCriteria)
+	return s
+}
+
+// Switch keeps the State of a kill switch ConfigMap current by watching it. It is safe for concurrent use.
+type Switch struct {
+	clientset kubernetes.Interface
+	c         conf.KillSwitch
+	cluster   string
+
+	mu    sync.RWMutex
+	state State
+}
+
+// New builds a Switch for the passed conf.KillSwitch and reads its initial State. A nil Switch is returned if the kill
+// switch is not enabled; its State is always the zero State. The metrics of the Switch are labeled with cluster.
+func New(clientset kubernetes.Interface, c conf.KillSwitch, cluster string) *Switch {
+	if !c.Enabled() {
+		return nil
+	}
+
+	s := &Switch{
+		clientset: clientset,
+		c:         c,
+		cluster:   cluster,
+	}
+
+	s.refresh()
+	return s
+}
+
+// State returns the current State of the kill switch.
+func (s *Switch) State() State {
+	if s == nil {
+		return State{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Watch keeps the State current until stop is closed. It re-establishes the watch whenever it ends.
+func (s *Switch) Watch(stop <-chan struct{}) {
+	if s == nil {
+		return
+	}
+
+	for {
+		s.watch(stop)
+		select {
+		case <-stop:
+			return
+		case <-time.After(retryInterval):
+			s.refresh()
+		}
+	}
+}
+
+func (s *Switch) watch(stop <-chan struct{}) {
+	w, err := s.clientset.CoreV1().ConfigMaps(s.c.Namespace).Watch(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", s.c.Name).String(),
+	})
+	if err != nil {
+		log.Printf("error watching kill switch %s/%s: %s", s.c.Namespace, s.c.Name, err)
+		return
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				if cm, ok := ev.Object.(*v1.ConfigMap); ok {
+					s.set(parse(cm))
+				}
+			case watch.Deleted:
+				s.set(State{})
+			}
+		}
+	}
+}
+
+// refresh reads the ConfigMap directly. On errors other than the ConfigMap not existing the previous State is kept.
+func (s *Switch) refresh() {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.c.Namespace).Get(s.c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		s.set(State{})
+		return
+	}
+
+	if err != nil {
+		log.Printf("error reading kill switch %s/%s, keeping previous state: %s", s.c.Namespace, s.c.Name, err)
+		return
+	}
+
+	s.set(parse(cm))
+}
+
+func (s *Switch) set(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state.String() != s.state.String() {
+		log.Printf("kill switch %s/%s changed: %s", s.c.Namespace, s.c.Name, state)
+	}
+
+	s.observe(s.state, state)
+	s.state = state
+}
+
+// observe exports the passed State as the metrics of the cluster of the Switch, dropping the criteria paused by the
+// previous State only. The metrics of other clusters are left alone.
+func (s *Switch) observe(prev, state State) {
+	metrics.KillSwitchPaused.WithLabelValues(s.cluster).Set(metrics.Bool(state.Paused))
+	metrics.KillSwitchDryRun.WithLabelValues(s.cluster).Set(metrics.Bool(state.DryRun))
+	for _, name := range prev.PausedCriteria {
+		metrics.KillSwitchCriteriaPaused.DeleteLabelValues(s.cluster, name)
+	}
+
+	for _, name := range state.PausedCriteria {
+		metrics.KillSwitchCriteriaPaused.WithLabelValues(s.cluster, name).Set(1)
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Every metric is labeled with the cluster it describes. The cluster label is empty when kicker manages a single
+// cluster without conf.Conf.Clusters.
+
+// ClusterReady is 1 once the pods of a cluster have been listed successfully.
+var ClusterReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "kicker",
+	Name:      "cluster_ready",
+	Help:      "1 once the pods of the cluster have been listed successfully.",
+}, []string{"cluster"})
+
+// OverlappingPods is the number of pods matched by a criteria that were also matched by another criteria at the last
+// evaluation.
+var OverlappingPods = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "kicker",
+	Name:      "overlapping_pods",
+	Help:      "Pods matched by the criteria that were also matched by another criteria at the last evaluation.",
+}, []string{"cluster", "criteria"})
+
+// KillSwitchPaused is 1 while the kill switch pauses all kicking.
+var KillSwitchPaused = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "kicker",
+	Name:      "kill_switch_paused",
+	Help:      "1 while the kill switch pauses all kicking.",
+}, []string{"cluster"})
+
+// KillSwitchDryRun is 1 while the kill switch forces dry run mode.
+var KillSwitchDryRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "kicker",
+	Name:      "kill_switch_dry_run",
+	Help:      "1 while the kill switch forces dry run mode.",
+}, []string{"cluster"})
+
+// KillSwitchCriteriaPaused is 1 for every criteria the kill switch pauses by name.
+var KillSwitchCriteriaPaused = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "kicker",
+	Name:      "kill_switch_criteria_paused",
+	Help:      "1 for every criteria the kill switch pauses by name.",
+}, []string{"cluster", "criteria"})
+
+func init() {
+	prometheus.MustRegister(ClusterReady, OverlappingPods, KillSwitchPaused, KillSwitchDryRun, KillSwitchCriteriaPaused)
+}
+
+// Bool converts b to a gauge value.
+func Bool(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// Handler returns the http.Handler serving the registered metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve serves the registered metrics on /metrics at addr in the background. Errors serving are logged.
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	go func() {
+		log.Printf("serving metrics on %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("error serving metrics: %s", err)
+		}
+	}()
+}
@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+)
+
+// EventKind identifies what kicker did to raise an Event
+type EventKind string
+
+const (
+	// EventKick is raised when a pod was kicked
+	EventKick EventKind = "kick"
+	// EventKickFailed is raised when kicking a pod returned an error
+	EventKickFailed EventKind = "kickFailed"
+	// EventSafeguardRefused is raised when a pod selected by a strategy was not kicked because a safeguard refused it
+	EventSafeguardRefused EventKind = "safeguardRefused"
+	// EventCriteriaStuck is raised when every kick of a criteria has failed for conf.Conf.StuckAfter evaluations
+	EventCriteriaStuck EventKind = "criteriaStuck"
+)
+
+// Event is the payload sent to webhooks. It is also the data a conf.Webhook.Template is rendered with.
+type Event struct {
+	Kind      EventKind `json:"kind"`
+	Time      time.Time `json:"time"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Criteria  string    `json:"criteria"`
+	Namespace string    `json:"namespace,omitempty"`
+	Pod       string    `json:"pod,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	DryRun    bool      `json:"dryRun,omitempty"`
+}
+
+// Notifier fans Events out to a set of webhooks. Each webhook has its own bounded queue and worker so that a slow or
+// unavailable webhook never blocks the caller.
+type Notifier struct {
+	hooks []*hook
+	wg    sync.WaitGroup
+}
+
+// New builds a Notifier for the passed webhooks and starts their workers. A Notifier without webhooks is valid and
+// discards all Events.
+func New(webhooks []conf.Webhook) *Notifier {
+	n := &Notifier{}
+	for _, wh := range webhooks {
+		h := newHook(wh)
+		n.hooks = append(n.hooks, h)
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			h.run()
+		}()
+	}
+
+	return n
+}
+
+// Notify queues the passed Event for every webhook interested in it. It never blocks; if a webhook's queue is full the
+// Event is dropped for that webhook.
+func (n *Notifier) Notify(ev Event) {
+	if n == nil {
+		return
+	}
+
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+
+	for _, h := range n.hooks {
+		if !h.wants(ev) {
+			continue
+		}
+
+		select {
+		case h.queue <- ev:
+		default:
+			log.Printf("webhook '%s' queue is full, dropping %s event for %s", h.conf.URL, ev.Kind, ev.Criteria)
+		}
+	}
+}
+
+// Close stops accepting Events and waits for all queued Events to be delivered or dropped.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+
+	for _, h := range n.hooks {
+		close(h.queue)
+	}
+
+	n.wg.Wait()
+}
+
+type hook struct {
+	conf     conf.Webhook
+	criteria map[string]bool
+	events   map[EventKind]bool
+	tmpl     *template.Template
+	client   *http.Client
+	queue    chan Event
+}
+
+func newHook(wh conf.Webhook) *hook {
+	h := &hook{
+		conf:   wh,
+		client: &http.Client{Timeout: time.Duration(wh.Timeout) * time.Second},
+		queue:  make(chan Event, wh.QueueSize),
+	}
+
+	if len(wh.Criteria) > 0 {
+		h.criteria = make(map[string]bool, len(wh.Criteria))
+		for _, c := range wh.Criteria {
+			h.criteria[c] = true
+		}
+	}
+
+	if len(wh.Events) > 0 {
+		h.events = make(map[EventKind]bool, len(wh.Events))
+		for _, e := range wh.Events {
+			h.events[EventKind(e)] = true
+		}
+	}
+
+	if wh.Template != "" {
+		// the template has already been validated by conf
+		h.tmpl = template.Must(template.New(wh.URL).Parse(wh.Template))
+	}
+
+	return h
+}
+
+func (h *hook) wants(ev Event) bool {
+	if h.criteria != nil && !h.criteria[ev.Criteria] {
+		return false
+	}
+
+	if h.events != nil && !h.events[ev.Kind] {
+		return false
+	}
+
+	return true
+}
+
+func (h *hook) run() {
+	for ev := range h.queue {
+		body, err := h.render(ev)
+		if err != nil {
+			log.Printf("webhook '%s' error rendering %s event: %s", h.conf.URL, ev.Kind, err)
+			continue
+		}
+
+		backoff := time.Second
+		for attempt := int64(0); ; attempt++ {
+			err = h.send(body)
+			if err == nil {
+				break
+			}
+
+			if attempt >= h.conf.MaxRetries {
+				log.Printf("webhook '%s' dropping %s event after %d attempts: %s", h.conf.URL, ev.Kind, attempt+1, err)
+				break
+			}
+
+			log.Printf("webhook '%s' error sending %s event, retrying in %s: %s", h.conf.URL, ev.Kind, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func (h *hook) render(ev Event) ([]byte, error) {
+	if h.tmpl == nil {
+		return json.Marshal(ev)
+	}
+
+	var buf bytes.Buffer
+	if err := h.tmpl.Execute(&buf, ev); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (h *hook) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"google.golang.org/grpc"
+)
+
+// UnixPrefix prefixes the path of a unix socket in a conf.Plugin.Address.
+const UnixPrefix = "unix://"
+
+// Client calls a plugin, launching it first if it is configured with a conf.Plugin.Command. A launched plugin that
+// exits is relaunched on the next call. It is safe for concurrent use.
+type Client struct {
+	c conf.Plugin
+
+	mu     sync.Mutex
+	conn   *grpc.ClientConn
+	cmd    *exec.Cmd
+	dir    string
+	exited chan struct{}
+}
+
+// NewClient builds a Client for the passed conf.Plugin. No connection is made until the first call.
+func NewClient(c conf.Plugin) *Client {
+	return &Client{c: c}
+}
+
+// Evaluate calls the plugin's Evaluate method.
+func (cl *Client) Evaluate(ctx context.Context, req *EvaluateRequest) (*EvaluateResponse, error) {
+	conn, err := cl.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &EvaluateResponse{}
+	if err := conn.Invoke(ctx, EvaluateMethod, req, resp, grpc.CallContentSubtype(jsonCodec{}.Name())); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Close closes the connection to the plugin and stops it if it was launched by the Client.
+func (cl *Client) Close() error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.closeLocked()
+}
+
+func (cl *Client) connect(ctx context.Context) (*grpc.ClientConn, error) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.conn != nil && !cl.hasExited() {
+		return cl.conn, nil
+	}
+
+	cl.closeLocked()
+	if cl.c.Address != "" {
+		opts := []grpc.DialOption{grpc.WithInsecure(), grpc.WithBlock()}
+		if strings.HasPrefix(cl.c.Address, UnixPrefix) {
+			opts = append(opts, withUnixDialer())
+		}
+
+		conn, err := grpc.DialContext(ctx, cl.c.Address, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to plugin at %s: %s", cl.c.Address, err)
+		}
+
+		cl.conn = conn
+		return conn, nil
+	}
+
+	return cl.launch(ctx)
+}
+
+// launch starts the plugin binary and connects to the socket it listens on.
+func (cl *Client) launch(ctx context.Context) (*grpc.ClientConn, error) {
+	dir, err := ioutil.TempDir("", "kicker-plugin")
+	if err != nil {
+		return nil, err
+	}
+
+	socket := filepath.Join(dir, "plugin.sock")
+	cmd := exec.Command(cl.c.Command[0], cl.c.Command[1:]...)
+	cmd.Env = append(os.Environ(), SocketEnv+"="+socket)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("error launching plugin %s: %s", cl.name(), err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		log.Printf("plugin %s exited: %v", cl.name(), err)
+		close(exited)
+	}()
+
+	cl.cmd, cl.dir, cl.exited = cmd, dir, exited
+	conn, err := grpc.DialContext(ctx, UnixPrefix+socket, grpc.WithInsecure(), grpc.WithBlock(), withUnixDialer())
+	if err != nil {
+		cl.closeLocked()
+		return nil, fmt.Errorf("error connecting to plugin %s: %s", cl.name(), err)
+	}
+
+	cl.conn = conn
+	log.Printf("launched plugin %s", cl.name())
+	return conn, nil
+}
+
+// withUnixDialer dials targets of the form UnixPrefix + path on the unix socket at path.
+func withUnixDialer() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", strings.TrimPrefix(addr, UnixPrefix))
+	})
+}
+
+func (cl *Client) hasExited() bool {
+	if cl.exited == nil {
+		return false
+	}
+
+	select {
+	case <-cl.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+func (cl *Client) closeLocked() error {
+	var err error
+	if cl.conn != nil {
+		err = cl.conn.Close()
+		cl.conn = nil
+	}
+
+	if cl.cmd != nil {
+		if !cl.hasExited() {
+			cl.cmd.Process.Kill()
+			<-cl.exited
+		}
+
+		cl.cmd, cl.exited = nil, nil
+	}
+
+	if cl.dir != "" {
+		os.RemoveAll(cl.dir)
+		cl.dir = ""
+	}
+
+	return err
+}
+
+func (cl *Client) name() string {
+	return strings.Join(cl.c.Command, " ")
+}
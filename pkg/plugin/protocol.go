@@ -0,0 +1,88 @@
+// Package plugin defines the protocol between kicker and out of process strategy plugins.
+//
+// The protocol is a single unary gRPC method, /kicker.plugin.v1.Strategy/Evaluate, whose messages are the
+// EvaluateRequest and EvaluateResponse types of this package encoded as JSON (content subtype "json"). Plugins written
+// in Go implement Plugin and call ServeFromEnv; plugins in other languages register a gRPC service with the same name,
+// method and JSON codec.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc/encoding"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// ServiceName is the gRPC service implemented by plugins
+	ServiceName = "kicker.plugin.v1.Strategy"
+
+	// EvaluateMethod is the full gRPC method called for every evaluation
+	EvaluateMethod = "/" + ServiceName + "/Evaluate"
+
+	// SocketEnv is the environment variable holding the unix socket a plugin launched by kicker must listen on
+	SocketEnv = "KICKER_PLUGIN_SOCKET"
+)
+
+// EvaluateRequest is sent to a plugin for every evaluation of its criteria.
+type EvaluateRequest struct {
+	// Criteria is the name of the criteria being evaluated
+	Criteria string `json:"criteria"`
+
+	// Namespace is the namespace of the criteria
+	Namespace string `json:"namespace"`
+
+	// Params are the conf.Plugin.Params of the criteria
+	Params map[string]string `json:"params"`
+
+	// Now is the time of the evaluation
+	Now time.Time `json:"now"`
+
+	// Pods are the pods matched by the criteria; only these may be selected
+	Pods []v1.Pod `json:"pods"`
+}
+
+// EvaluateResponse holds the pods a plugin selected for kicking.
+type EvaluateResponse struct {
+	// Selected are the selected pods, in the order they should be kicked
+	Selected []Selection `json:"selected"`
+}
+
+// Selection is a single pod selected by a plugin.
+type Selection struct {
+	// Namespace is the namespace of the selected pod
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the selected pod
+	Name string `json:"name"`
+
+	// Reason describes why the pod was selected
+	Reason string `json:"reason"`
+}
+
+// Plugin is implemented by strategy plugins.
+type Plugin interface {
+	// Evaluate selects pods to kick from the passed request
+	Evaluate(ctx context.Context, req *EvaluateRequest) (*EvaluateResponse, error)
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes protocol messages as JSON.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*Plugin)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Evaluate",
+			Handler:    evaluateHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "kicker/plugin",
+}
+
+func evaluateHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &EvaluateRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(Plugin).Evaluate(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EvaluateMethod,
+	}
+
+	return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Plugin).Evaluate(ctx, req.(*EvaluateRequest))
+	})
+}
+
+// Register registers the passed Plugin with a gRPC server.
+func Register(s *grpc.Server, p Plugin) {
+	s.RegisterService(&serviceDesc, p)
+}
+
+// Serve serves the passed Plugin on lis until it fails.
+func Serve(lis net.Listener, p Plugin) error {
+	s := grpc.NewServer()
+	Register(s, p)
+	return s.Serve(lis)
+}
+
+// ServeFromEnv serves the passed Plugin on the unix socket kicker passed in SocketEnv. It is meant to be called from
+// the main function of a plugin launched by kicker. The process exits once kicker does.
+func ServeFromEnv(p Plugin) error {
+	path := os.Getenv(SocketEnv)
+	if path == "" {
+		return fmt.Errorf("%s is not set, the plugin must be launched by kicker", SocketEnv)
+	}
+
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	parent := os.Getppid()
+	go func() {
+		for range time.Tick(time.Second) {
+			if os.Getppid() != parent {
+				os.Exit(0)
+			}
+		}
+	}()
+
+	return Serve(lis, p)
+}
@@ -1,7 +1,12 @@
 package eval
 
 import (
+	_ "github.com/curlymon/kicker/pkg/strategy/cleanup"    // imports the default cleanup strategy
 	_ "github.com/curlymon/kicker/pkg/strategy/immediate"  // imports the default immediate strategy
+	_ "github.com/curlymon/kicker/pkg/strategy/plugin"     // imports the default plugin strategy
+	_ "github.com/curlymon/kicker/pkg/strategy/random"     // imports the default random strategy
 	_ "github.com/curlymon/kicker/pkg/strategy/spread"     // imports the default spread strategy
 	_ "github.com/curlymon/kicker/pkg/strategy/spreadfast" // imports the default spreadfast strategy
+	_ "github.com/curlymon/kicker/pkg/strategy/stages"     // imports the default pipeline stages and filters
+	_ "github.com/curlymon/kicker/pkg/strategy/triggered"  // imports the default triggered strategy
 )
@@ -0,0 +1,42 @@
+package cleanup
+
+import (
+	"log"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/strategy"
+)
+
+func init() {
+	if err := strategy.RegisterLookupEvaluatorConstructor(conf.StrategyCleanup, Cleanup); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Cleanup defines the cleanup strategy evaluation.
+// It first filters the passed list of pods to the setting defined in the passed conf.Criteria, which for a cleanup
+// conf.Criteria.Kind keeps only terminating, failed or succeeded pods.
+// Then it sorts pods by how long they have been in that state, longest first, and removes pods that have been in it for
+// less than conf.Criteria.MinAge.
+// Last it kicks pods in order until conf.Criteria.Limit, resolved against the matched pods, is reached.
+// If a pod is kicked, a cooldown for re-evaluation is triggered with a length of conf.Criteria.CoolDown.
+func Cleanup(c conf.Criteria, l strategy.Lookups) strategy.Evaluator {
+	// build a logic core that assumes filtered pods
+	core := strategy.EvaluatorSeive(
+		strategy.SortStateSinceAsc(c.Kind),
+		strategy.InStateLongerThan(c.Kind, time.Duration(c.MinAge)*time.Second),
+	)
+
+	// build a filter top remove all non matching pods
+	filter := strategy.CriteriaFilter(c)
+
+	// setup prefilter
+	prefilter := strategy.EvaluatorSeive(
+		strategy.ApplyFilter(filter),
+		strategy.LimitFor(c, l, core),
+	)
+
+	// wrap prefilter strategy with cooldown
+	return strategy.CoolDownFor(time.Duration(c.CoolDown)*time.Second, l, prefilter)
+}
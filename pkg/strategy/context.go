@@ -0,0 +1,183 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// Input is everything a ContextEvaluator is evaluated with.
+type Input struct {
+	// Pods are the pods to evaluate
+	Pods []v1.Pod
+
+	// Now is the time of the evaluation
+	Now time.Time
+
+	// Criteria is the conf.Criteria being evaluated
+	Criteria conf.Criteria
+
+	// Lookups provide cluster information beyond Pods
+	Lookups Lookups
+}
+
+// Result is the outcome of a ContextEvaluator.
+type Result struct {
+	// Selected are the pods selected for kicking, in the order they should be kicked
+	Selected []v1.Pod
+
+	// Reasons holds why pods were selected, keyed by namespace/name. A selected pod may have no reason.
+	Reasons map[string]string
+}
+
+// Reason returns why the passed pod was selected, or an empty string if no reason was given.
+func (r Result) Reason(pod v1.Pod) string {
+	return r.Reasons[pod.Namespace+"/"+pod.Name]
+}
+
+// ContextEvaluator is an evaluation kernel that can be cancelled and can fail, e.g. because it performs I/O. A
+// ContextEvaluator that returns an error selects nothing.
+type ContextEvaluator interface {
+	// EvaluateContext selects the pods to kick from the passed Input
+	EvaluateContext(ctx context.Context, in Input) (Result, error)
+}
+
+// ContextEvaluatorFunc adapts a function to a ContextEvaluator.
+type ContextEvaluatorFunc func(ctx context.Context, in Input) (Result, error)
+
+// EvaluateContext implements ContextEvaluator.
+func (f ContextEvaluatorFunc) EvaluateContext(ctx context.Context, in Input) (Result, error) {
+	return f(ctx, in)
+}
+
+// FromEvaluator adapts an Evaluator to a ContextEvaluator. The Evaluator is not run if ctx is already done; once running
+// it cannot be cancelled and never fails.
+func FromEvaluator(eval Evaluator) ContextEvaluator {
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+
+		return Result{Selected: eval(in.Pods)}, nil
+	})
+}
+
+// Before returns a ContextEvaluator that passes the pods through eval before evaluating next, e.g. to prefilter them.
+func Before(eval Evaluator, next ContextEvaluator) ContextEvaluator {
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		in.Pods = eval(in.Pods)
+		if len(in.Pods) <= 0 {
+			return Result{}, nil
+		}
+
+		return next.EvaluateContext(ctx, in)
+	})
+}
+
+// After returns a ContextEvaluator that passes the pods selected by prev through eval, e.g. to cap them. Reasons are
+// kept for the pods eval keeps.
+func After(prev ContextEvaluator, eval Evaluator) ContextEvaluator {
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		res, err := prev.EvaluateContext(ctx, in)
+		if err != nil || len(res.Selected) <= 0 {
+			return res, err
+		}
+
+		res.Selected = eval(res.Selected)
+		return res, nil
+	})
+}
+
+// LimitForContext is LimitFor for a ContextEvaluator: it truncates the selection of next to conf.Criteria.Limit of c,
+// resolved against the pods it is passed, keeping the reasons of the pods it keeps. Errors of next are returned as is.
+func LimitForContext(c conf.Criteria, l Lookups, next ContextEvaluator) ContextEvaluator {
+	limit := newLimiter(c, l)
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		res, err := next.EvaluateContext(ctx, in)
+		if err != nil || len(res.Selected) <= 0 {
+			return res, err
+		}
+
+		res.Selected = limit.apply(in.Pods, res.Selected)
+		return res, nil
+	})
+}
+
+// CoolDownContext is CoolDown for a ContextEvaluator: once next selects any pods it is not evaluated again until cd has
+// passed. Evaluations that fail do not start a cool down.
+func CoolDownContext(cd time.Duration, next ContextEvaluator) ContextEvaluator {
+	return CoolDownForContext(cd, Lookups{}, next)
+}
+
+// CoolDownForContext is CoolDownFor for a ContextEvaluator: the cool down starts once a pod selected by next is kicked.
+func CoolDownForContext(cd time.Duration, l Lookups, next ContextEvaluator) ContextEvaluator {
+	var until time.Time
+	window := newKickCommit(l, func(pod v1.Pod, at time.Time) {
+		until = at.Add(cd)
+	})
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		if timeNow().Before(until) {
+			return Result{}, nil
+		}
+
+		res, err := next.EvaluateContext(ctx, in)
+		if err == nil {
+			window.record(res.Selected, timeNow())
+		}
+
+		return res, err
+	})
+}
+
+// ContextEvaluatorConstructor defines a constructor function for a ContextEvaluator
+type ContextEvaluatorConstructor func(conf.Criteria, Lookups) ContextEvaluator
+
+var contextRegistry = map[conf.Strategy]ContextEvaluatorConstructor{}
+
+// RegisterContextEvaluatorConstructor registers a ContextEvaluatorConstructor for use with a given name. This can then
+// be referenced from a conf.Criteria.Strategy for use. A name may only be registered once across all kinds of
+// constructors.
+func RegisterContextEvaluatorConstructor(strat conf.Strategy, con ContextEvaluatorConstructor) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if registered(strat) {
+		return fmt.Errorf("strategy '%s' is already registered", strat)
+	}
+
+	contextRegistry[strat] = con
+
+	return nil
+}
+
+// RetrieveContextEvaluatorConstructor retrieves a registered ContextEvaluatorConstructor. Strategies registered with
+// RegisterEvaluatorConstructor or RegisterLookupEvaluatorConstructor are adapted with FromEvaluator.
+func RetrieveContextEvaluatorConstructor(strat conf.Strategy) (ContextEvaluatorConstructor, error) {
+	mu.RLock()
+	con, ok := contextRegistry[strat]
+	mu.RUnlock()
+	if ok {
+		return con, nil
+	}
+
+	stratCon, err := RetrieveLookupEvaluatorConstructor(strat)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c conf.Criteria, l Lookups) ContextEvaluator {
+		return FromEvaluator(stratCon(c, l))
+	}, nil
+}
+
+// logReasons logs why each selected pod was selected.
+func logReasons(c conf.Criteria, res Result) {
+	for _, pod := range res.Selected {
+		if reason := res.Reason(pod); reason != "" {
+			log.Printf("%s selected pod %s/%s: %s", c.Name, pod.Namespace, pod.Name, reason)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// reasoned returns a ContextEvaluator selecting the named pods, giving each the reason "picked".
+func reasoned(names ...string) ContextEvaluator {
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		res := Result{Reasons: map[string]string{}}
+		for _, pod := range in.Pods {
+			for _, name := range names {
+				if pod.Name == name {
+					res.Selected = append(res.Selected, pod)
+					res.Reasons[pod.Namespace+"/"+pod.Name] = "picked"
+				}
+			}
+		}
+
+		return res, nil
+	})
+}
+
+// failing returns a ContextEvaluator that fails with err, counting its calls in calls.
+func failing(err error, calls *int) ContextEvaluator {
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		*calls++
+		return Result{}, err
+	})
+}
+
+// counting returns an Evaluator that keeps all pods, counting its calls in calls.
+func counting(calls *int) Evaluator {
+	return func(pods []v1.Pod) []v1.Pod {
+		*calls++
+		return pods
+	}
+}
+
+func withoutPod(name string) Evaluator {
+	return ApplyFilter(func(pod v1.Pod) bool {
+		return pod.Name != name
+	})
+}
+
+func TestFromEvaluator(t *testing.T) {
+	var calls int
+	eval := FromEvaluator(counting(&calls))
+
+	res, err := eval.EvaluateContext(context.Background(), Input{Pods: fleetOf(3, time.Now())})
+	if err != nil || !reflect.DeepEqual(podNames(res.Selected), []string{"web-1", "web-2", "web-3"}) {
+		t.Errorf("EvaluateContext() = %v, %v, want all pods", podNames(res.Selected), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	if res, err := eval.EvaluateContext(ctx, Input{Pods: fleetOf(3, time.Now())}); err != context.Canceled || len(res.Selected) != 0 {
+		t.Errorf("EvaluateContext() = %v, %v, want nothing and %v", podNames(res.Selected), err, context.Canceled)
+	}
+
+	if calls != 0 {
+		t.Errorf("evaluator was called %d times with a done context, want 0", calls)
+	}
+}
+
+func TestBefore(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name     string
+		eval     Evaluator
+		next     func(calls *int) ContextEvaluator
+		selected []string
+		calls    int
+		err      error
+	}{
+		{
+			name:     "filters before next",
+			eval:     withoutPod("web-2"),
+			next:     func(calls *int) ContextEvaluator { return FromEvaluator(counting(calls)) },
+			selected: []string{"web-1", "web-3"},
+			calls:    1,
+		},
+		{
+			name:     "nothing left skips next",
+			eval:     ApplyFilter(func(pod v1.Pod) bool { return false }),
+			next:     func(calls *int) ContextEvaluator { return FromEvaluator(counting(calls)) },
+			selected: []string{},
+		},
+		{
+			name:     "error of next",
+			eval:     withoutPod("web-2"),
+			next:     func(calls *int) ContextEvaluator { return failing(boom, calls) },
+			selected: []string{},
+			calls:    1,
+			err:      boom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			res, err := Before(tt.eval, tt.next(&calls)).EvaluateContext(context.Background(), Input{Pods: fleetOf(3, time.Now())})
+			if err != tt.err {
+				t.Errorf("EvaluateContext() error = %v, want %v", err, tt.err)
+			}
+
+			if got := podNames(res.Selected); !reflect.DeepEqual(got, tt.selected) {
+				t.Errorf("selected = %v, want %v", got, tt.selected)
+			}
+
+			if calls != tt.calls {
+				t.Errorf("next was called %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestBeforePropagatesCancellation(t *testing.T) {
+	var calls int
+	eval := Before(withoutPod("web-2"), FromEvaluator(counting(&calls)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := eval.EvaluateContext(ctx, Input{Pods: fleetOf(3, time.Now())}); err != context.Canceled {
+		t.Errorf("EvaluateContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	if calls != 0 {
+		t.Errorf("evaluator was called %d times with a done context, want 0", calls)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	res, err := After(reasoned("web-1", "web-3"), withoutPod("web-1")).EvaluateContext(context.Background(), Input{Pods: fleetOf(3, time.Now())})
+	if err != nil || !reflect.DeepEqual(podNames(res.Selected), []string{"web-3"}) {
+		t.Errorf("EvaluateContext() = %v, %v, want web-3", podNames(res.Selected), err)
+	}
+
+	if reason := res.Reason(res.Selected[0]); reason != "picked" {
+		t.Errorf("reason = '%s', want the reason of the previous evaluator", reason)
+	}
+
+	boom := errors.New("boom")
+	var prevCalls, calls int
+	res, err = After(failing(boom, &prevCalls), counting(&calls)).EvaluateContext(context.Background(), Input{Pods: fleetOf(3, time.Now())})
+	if err != boom || len(res.Selected) != 0 {
+		t.Errorf("EvaluateContext() = %v, %v, want nothing and %v", podNames(res.Selected), err, boom)
+	}
+
+	if prevCalls != 1 || calls != 0 {
+		t.Errorf("previous evaluator was called %d times and evaluator %d times, want 1 and 0", prevCalls, calls)
+	}
+}
+
+func TestLimitForContext(t *testing.T) {
+	c := conf.Criteria{Name: "web", Limit: conf.Limit{Count: 1}}
+	res, err := LimitForContext(c, Lookups{}, reasoned("web-1", "web-3")).EvaluateContext(context.Background(), Input{Pods: fleetOf(3, time.Now())})
+	if err != nil || !reflect.DeepEqual(podNames(res.Selected), []string{"web-1"}) {
+		t.Errorf("EvaluateContext() = %v, %v, want web-1", podNames(res.Selected), err)
+	}
+
+	if reason := res.Reason(res.Selected[0]); reason != "picked" {
+		t.Errorf("reason = '%s', want the reason of the wrapped evaluator", reason)
+	}
+
+	boom := errors.New("boom")
+	var calls int
+	if _, err := LimitForContext(c, Lookups{}, failing(boom, &calls)).EvaluateContext(context.Background(), Input{Pods: fleetOf(3, time.Now())}); err != boom {
+		t.Errorf("EvaluateContext() error = %v, want %v", err, boom)
+	}
+}
+
+func TestCoolDownContext(t *testing.T) {
+	clock, restore := useFakeClock(time.Now())
+	defer restore()
+
+	fail := true
+	next := ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		if fail {
+			return Result{}, errors.New("boom")
+		}
+
+		return reasoned("web-1").EvaluateContext(ctx, in)
+	})
+	eval := CoolDownContext(10*time.Minute, next)
+	in := Input{Pods: fleetOf(3, time.Now())}
+
+	if _, err := eval.EvaluateContext(context.Background(), in); err == nil {
+		t.Fatalf("expected the failing evaluation to return its error")
+	}
+
+	fail = false
+	if res, err := eval.EvaluateContext(context.Background(), in); err != nil || len(res.Selected) != 1 {
+		t.Fatalf("selected = %v, error = %v, want web-1 as failures do not start the cool down", podNames(res.Selected), err)
+	}
+
+	clock.advance(5 * time.Minute)
+	if res, _ := eval.EvaluateContext(context.Background(), in); len(res.Selected) != 0 {
+		t.Errorf("selected = %v during the cool down, want nothing", podNames(res.Selected))
+	}
+
+	clock.advance(5 * time.Minute)
+	if res, _ := eval.EvaluateContext(context.Background(), in); len(res.Selected) != 1 {
+		t.Errorf("selected = %v after the cool down, want web-1", podNames(res.Selected))
+	}
+}
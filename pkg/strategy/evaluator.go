@@ -5,9 +5,13 @@ import (
 	"sort"
 	"time"
 
+	"github.com/curlymon/kicker/pkg/conf"
 	"k8s.io/api/core/v1"
 )
 
+// timeNow returns the current time. It is replaced in tests to control time based evaluators.
+var timeNow = time.Now
+
 func EvaluatorSeive(evaluators ...Evaluator) Evaluator {
 	return func(pods []v1.Pod) []v1.Pod {
 		log.Printf("EvaluatorSeive called with %d pods", len(pods))
@@ -87,7 +91,7 @@ func SortCreationTimestampAsc(pods []v1.Pod) []v1.Pod {
 func OlderThan(maxAge time.Duration) Evaluator {
 	return func(pods []v1.Pod) []v1.Pod {
 		log.Printf("OlderThan called with %d pods", len(pods))
-		maxT := time.Now().Add(-maxAge)
+		maxT := timeNow().Add(-maxAge)
 		out := make([]v1.Pod, 0, len(pods))
 		for i := range pods {
 			if pods[i].CreationTimestamp.Time.Before(maxT) {
@@ -102,55 +106,156 @@ func OlderThan(maxAge time.Duration) Evaluator {
 	}
 }
 
+// StateSince returns when the passed v1.Pod entered the state targeted by the passed conf.Kind: its deletion deadline for
+// terminating pods, the time its last container finished for failed and succeeded pods, and its creation otherwise.
+// Finished pods without container statuses, e.g. evicted ones, fall back to their start time then their creation.
+func StateSince(kind conf.Kind, pod v1.Pod) time.Time {
+	switch kind {
+	case conf.KindTerminating:
+		if pod.DeletionTimestamp != nil {
+			return pod.DeletionTimestamp.Time
+		}
+	case conf.KindFailed, conf.KindSucceeded:
+		var finished time.Time
+		for _, cs := range pod.Status.ContainerStatuses {
+			if t := cs.State.Terminated; t != nil && t.FinishedAt.Time.After(finished) {
+				finished = t.FinishedAt.Time
+			}
+		}
+
+		if !finished.IsZero() {
+			return finished
+		}
+
+		if pod.Status.StartTime != nil {
+			return pod.Status.StartTime.Time
+		}
+	}
+
+	return pod.CreationTimestamp.Time
+}
+
+// SortStateSinceAsc returns an Evaluator sorting pods by how long they have been in the state targeted by the passed
+// conf.Kind, longest first.
+func SortStateSinceAsc(kind conf.Kind) Evaluator {
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("SortStateSinceAsc called with %d pods", len(pods))
+		sort.Slice(pods, func(i, j int) bool {
+			return StateSince(kind, pods[i]).Before(StateSince(kind, pods[j]))
+		})
+
+		log.Printf("SortStateSinceAsc exiting with %d pods", len(pods))
+		return pods
+	}
+}
+
+// InStateLongerThan returns an Evaluator keeping pods that have been in the state targeted by the passed conf.Kind for
+// longer than age.
+func InStateLongerThan(kind conf.Kind, age time.Duration) Evaluator {
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("InStateLongerThan called with %d pods", len(pods))
+		maxT := timeNow().Add(-age)
+		out := make([]v1.Pod, 0, len(pods))
+		for i := range pods {
+			if StateSince(kind, pods[i]).Before(maxT) {
+				out = append(out, pods[i])
+			}
+		}
+
+		out = out[:len(out):len(out)]
+
+		log.Printf("InStateLongerThan exiting with %d pods", len(out))
+		return out
+	}
+}
+
+// CoolDown returns an Evaluator that once eval selects any pods does not call it again until cd has passed. The cool
+// down starts as soon as pods are selected; use CoolDownFor to start it once they are kicked.
 func CoolDown(cd time.Duration, eval Evaluator) Evaluator {
+	return CoolDownFor(cd, Lookups{}, eval)
+}
+
+// CoolDownFor is CoolDown started by the kick of a pod eval selected rather than by the selection, see Lookups.OnKick,
+// so that pods refused by the engine's safeguards do not hold back the next evaluation.
+func CoolDownFor(cd time.Duration, l Lookups, eval Evaluator) Evaluator {
 	cdWait := time.Time{}
+	window := newKickCommit(l, func(pod v1.Pod, at time.Time) {
+		log.Printf("CoolDown setting cool down for %s", cd)
+		cdWait = at.Add(cd)
+	})
 	return func(pods []v1.Pod) []v1.Pod {
 		log.Printf("CoolDown called with %d pods", len(pods))
-		if time.Now().Before(cdWait) {
+		if timeNow().Before(cdWait) {
 			log.Println("CoolDown exiting early due to cool down")
 			return nil
 		}
 
 		pods = eval(pods)
-
-		if len(pods) > 0 {
-			log.Printf("CoolDown setting cool down for %s", cd)
-			cdWait = time.Now().Add(cd)
-		}
+		window.record(pods, timeNow())
 
 		log.Printf("CoolDown exiting with %d pods", len(pods))
 		return pods
 	}
 }
 
+// Spread returns an Evaluator that spaces kicks of a fleet evenly over maxAge. After a kick, eval is not called again
+// until maxAge divided by the current fleet size has passed, so the interval shrinks as the fleet scales up and grows as
+// it scales down. If the fleet is larger than it was at the last kick the interval is ignored. The fleet is the pods
+// passed to the Evaluator, which must already be filtered to the pods the strategy targets; an empty fleet selects
+// nothing. The interval starts as soon as pods are selected; use SpreadFor to start it once they are kicked.
 func Spread(maxAge time.Duration, eval Evaluator) Evaluator {
-	waitUntil := time.Time{}
+	return SpreadFor(maxAge, Lookups{}, eval)
+}
+
+// SpreadFor is Spread started by the kick of a pod eval selected rather than by the selection, see Lookups.OnKick.
+func SpreadFor(maxAge time.Duration, l Lookups, eval Evaluator) Evaluator {
+	var lastKick time.Time
+	var lastFleet, fleet int
+	window := newKickCommit(l, func(pod v1.Pod, at time.Time) {
+		log.Printf("Spread setting cool down for %s at %d pods", maxAge/time.Duration(fleet), fleet)
+		lastKick = at
+		lastFleet = fleet
+	})
 	return func(pods []v1.Pod) []v1.Pod {
 		log.Printf("Spread called with %d pods", len(pods))
-		if time.Now().Before(waitUntil) {
-			log.Println("Spread exiting early due to spread cool down")
+		fleet = len(pods)
+		if fleet <= 0 {
+			log.Println("Spread exiting early due to empty fleet")
 			return nil
 		}
 
-		maxT := maxAge / time.Duration(len(pods))
-		pods = eval(pods)
-
-		if len(pods) > 0 {
-			log.Printf("Spread setting cool down for %s", maxT)
-			waitUntil = time.Now().Add(maxT)
+		now := timeNow()
+		interval := maxAge / time.Duration(fleet)
+		if !lastKick.IsZero() && fleet <= lastFleet && now.Before(lastKick.Add(interval)) {
+			log.Printf("Spread exiting early due to spread cool down of %s for %d pods", interval, fleet)
+			return nil
 		}
 
+		pods = eval(pods)
+		window.record(pods, now)
+
 		log.Printf("Spread exiting with %d pods", len(pods))
 		return pods
 	}
 }
 
+// SpreadFast returns an Evaluator that kicks at most limit of the pods selected by eval once every maxAge divided by the
+// fleet size, only selecting pods older than that interval. The interval starts as soon as pods are selected.
+//
+// Deprecated: SpreadFast filters by age after eval and limits with a fixed count; use SpreadFastFor with OlderThanShare
+// and LimitFor, which filter before limiting, resolve conf.Criteria.Limit against the fleet and start the interval once
+// a pod is kicked.
 func SpreadFast(maxAge time.Duration, limit int64, eval Evaluator) Evaluator {
 	lastEvict := time.Time{}
 	return func(pods []v1.Pod) []v1.Pod {
 		log.Printf("SpreadFast called with %d pods", len(pods))
+		if len(pods) <= 0 {
+			log.Println("SpreadFast exiting early due to empty fleet")
+			return nil
+		}
+
 		minAge := maxAge / time.Duration(len(pods))
-		if time.Now().Before(lastEvict.Add(minAge)) {
+		if timeNow().Before(lastEvict.Add(minAge)) {
 			log.Println("SpreadFast exiting early due to spread cool down")
 			return nil
 		}
@@ -161,14 +266,60 @@ func SpreadFast(maxAge time.Duration, limit int64, eval Evaluator) Evaluator {
 
 		if len(pods) > 0 {
 			log.Printf("SpreadFast setting cool down for %s", minAge)
-			lastEvict = time.Now()
+			lastEvict = timeNow()
+		}
+
+		log.Printf("SpreadFast exiting with %d pods", len(pods))
+		return pods
+	}
+}
+
+// SpreadFastFor returns an Evaluator that calls eval at most once every maxAge divided by the fleet size, the interval
+// starting once a pod eval selected is kicked, see Lookups.OnKick. eval is passed the whole fleet so that limits are
+// resolved against it; to only kick pods older than the interval, as the spreadfast strategy does, pass the fleet
+// through OlderThanShare within eval, before it is limited.
+func SpreadFastFor(maxAge time.Duration, l Lookups, eval Evaluator) Evaluator {
+	lastEvict := time.Time{}
+	var minAge time.Duration
+	window := newKickCommit(l, func(pod v1.Pod, at time.Time) {
+		log.Printf("SpreadFast setting cool down for %s", minAge)
+		lastEvict = at
+	})
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("SpreadFast called with %d pods", len(pods))
+		if len(pods) <= 0 {
+			log.Println("SpreadFast exiting early due to empty fleet")
+			return nil
+		}
+
+		minAge = maxAge / time.Duration(len(pods))
+		if timeNow().Before(lastEvict.Add(minAge)) {
+			log.Println("SpreadFast exiting early due to spread cool down")
+			return nil
 		}
 
+		pods = eval(pods)
+		window.record(pods, timeNow())
+
 		log.Printf("SpreadFast exiting with %d pods", len(pods))
 		return pods
 	}
 }
 
+// OlderThanShare returns an Evaluator keeping pods older than maxAge divided by the number of pods it is passed, the
+// interval SpreadFastFor spaces the kicks of that fleet by. This prevents kicking as soon as a fleet is first evaluated,
+// unless it actually has a pod that is due.
+func OlderThanShare(maxAge time.Duration) Evaluator {
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("OlderThanShare called with %d pods", len(pods))
+		if len(pods) <= 0 {
+			return nil
+		}
+
+		return OlderThan(maxAge / time.Duration(len(pods)))(pods)
+	}
+}
+
 func Limit(limit int64) Evaluator {
 	return func(pods []v1.Pod) []v1.Pod {
 		log.Printf("Limit called with %d pods", len(pods))
@@ -180,3 +331,55 @@ func Limit(limit int64) Evaluator {
 		return pods
 	}
 }
+
+// LimitFor returns an Evaluator that resolves the conf.Criteria.Limit of c against the pods it is passed and truncates
+// the result of eval to it. It must wrap an Evaluator passed only the pods matched by c so that percentage limits are
+// relative to the matched fleet, or with conf.LimitOfReplicas to the desired replicas of the fleet's owners.
+// When conf.Criteria.Triggers are set only the pods of the result of eval that the triggers fired for are kept, after
+// the limit is resolved against the whole fleet. When conf.Criteria.Topology is enabled the result of eval is passed
+// through TopologySpreadFor while truncating, so pods it refuses neither count towards the limit nor prevent the next
+// pods in order from being selected.
+func LimitFor(c conf.Criteria, l Lookups, eval Evaluator) Evaluator {
+	limit := newLimiter(c, l)
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("LimitFor called with %d pods", len(pods))
+		pods = limit.apply(pods, eval(pods))
+		log.Printf("LimitFor exiting with %d pods", len(pods))
+		return pods
+	}
+}
+
+// limiter truncates selections to conf.Criteria.Limit as described by LimitFor.
+type limiter struct {
+	c        conf.Criteria
+	l        Lookups
+	topology *topologySpread
+}
+
+func newLimiter(c conf.Criteria, l Lookups) *limiter {
+	lim := &limiter{c: c, l: l}
+	if t := c.Topology; t.Enabled() {
+		lim.topology = newTopologySpread(t.Key, t.MaxPerNode, t.MaxPerDomain, time.Duration(t.Window)*time.Second, l)
+	}
+
+	return lim
+}
+
+// apply truncates the triggered pods of selected to the limit resolved against fleet.
+func (lim *limiter) apply(fleet, selected []v1.Pod) []v1.Pod {
+	size := len(fleet)
+	if lim.c.LimitPolicy.Of == conf.LimitOfReplicas && lim.l.Replicas != nil {
+		size = desiredReplicas(fleet, lim.l.Replicas)
+	}
+
+	limit := lim.c.ResolveLimit(size)
+	if lim.c.Triggers.Enabled() {
+		selected = ApplyFilter(TriggeredFilter(lim.c, lim.l.Triggered))(selected)
+	}
+
+	if lim.topology != nil {
+		return lim.topology.pick(selected, limit)
+	}
+
+	return Limit(limit)(selected)
+}
@@ -0,0 +1,239 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// step is a single evaluation of a time based evaluator in a test.
+type step struct {
+	// after is how long after the previous step the evaluation happens
+	after time.Duration
+
+	// fleet is the number of pods evaluated
+	fleet int
+
+	// kicks is whether any pod is expected to be selected
+	kicks bool
+}
+
+// fakeClock replaces timeNow with a clock that only moves when advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+// useFakeClock makes timeNow return start until advanced. The returned func restores timeNow.
+func useFakeClock(start time.Time) (*fakeClock, func()) {
+	c := &fakeClock{now: start}
+	timeNow = func() time.Time {
+		return c.now
+	}
+
+	return c, func() {
+		timeNow = time.Now
+	}
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// runningPod returns a running pod, the fixture every test of this package builds its pods from.
+func runningPod(namespace, name string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+// fleetOf returns n running pods of the default namespace named web-1 to web-n, all created at created.
+func fleetOf(n int, created time.Time) []v1.Pod {
+	pods := make([]v1.Pod, 0, n)
+	for i := 1; i <= n; i++ {
+		pod := runningPod("default", fmt.Sprintf("web-%d", i))
+		pod.CreationTimestamp = metav1.NewTime(created)
+		pods = append(pods, pod)
+	}
+
+	return pods
+}
+
+func podNames(pods []v1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+
+	return names
+}
+
+func runSteps(t *testing.T, build func(Evaluator) Evaluator, age time.Duration, steps []step) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock, restore := useFakeClock(start)
+	defer restore()
+
+	eval := build(Limit(1))
+	elapsed := time.Duration(0)
+	for i, s := range steps {
+		clock.advance(s.after)
+		elapsed += s.after
+		got := eval(fleetOf(s.fleet, start.Add(-age)))
+		if kicked := len(got) > 0; kicked != s.kicks {
+			t.Errorf("step %d at %s with %d pods: kicked = %t, want %t", i, elapsed, s.fleet, kicked, s.kicks)
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "empty fleet selects nothing",
+			steps: []step{
+				{fleet: 0, kicks: false},
+				{fleet: 3, kicks: true},
+			},
+		},
+		{
+			name: "interval holds while the fleet is unchanged",
+			steps: []step{
+				{fleet: 4, kicks: true},
+				{after: 10 * time.Minute, fleet: 4, kicks: false},
+				{after: 5 * time.Minute, fleet: 4, kicks: true},
+			},
+		},
+		{
+			name: "scale up ignores the interval",
+			steps: []step{
+				{fleet: 4, kicks: true},
+				{after: time.Minute, fleet: 5, kicks: true},
+				{after: time.Minute, fleet: 5, kicks: false},
+			},
+		},
+		{
+			name: "scale down lengthens the interval",
+			steps: []step{
+				{fleet: 4, kicks: true},
+				{after: 16 * time.Minute, fleet: 2, kicks: false},
+				{after: 13 * time.Minute, fleet: 2, kicks: false},
+				{after: time.Minute, fleet: 2, kicks: true},
+			},
+		},
+		{
+			name: "interval is recomputed from the current fleet",
+			steps: []step{
+				{fleet: 4, kicks: true},
+				{after: 16 * time.Minute, fleet: 2, kicks: false},
+				{fleet: 4, kicks: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, func(eval Evaluator) Evaluator {
+				return Spread(time.Hour, eval)
+			}, 2*time.Hour, tt.steps)
+		})
+	}
+}
+
+func TestSpreadEmptyFleetDoesNotEvaluate(t *testing.T) {
+	var called bool
+	eval := Spread(time.Hour, func(pods []v1.Pod) []v1.Pod {
+		called = true
+		return pods
+	})
+
+	if got := eval(nil); got != nil {
+		t.Errorf("Spread(nil) = %v, want nil", got)
+	}
+
+	if called {
+		t.Error("Spread called eval with an empty fleet")
+	}
+}
+
+func TestSpreadFast(t *testing.T) {
+	tests := []struct {
+		name  string
+		age   time.Duration
+		steps []step
+	}{
+		{
+			name: "empty fleet selects nothing",
+			age:  2 * time.Hour,
+			steps: []step{
+				{fleet: 0, kicks: false},
+				{fleet: 3, kicks: true},
+			},
+		},
+		{
+			name: "interval holds after a kick",
+			age:  2 * time.Hour,
+			steps: []step{
+				{fleet: 4, kicks: true},
+				{after: 10 * time.Minute, fleet: 4, kicks: false},
+				{after: 5 * time.Minute, fleet: 4, kicks: true},
+			},
+		},
+		{
+			name: "scale down lengthens the interval",
+			age:  2 * time.Hour,
+			steps: []step{
+				{fleet: 4, kicks: true},
+				{after: 16 * time.Minute, fleet: 2, kicks: false},
+				{after: 14 * time.Minute, fleet: 2, kicks: true},
+			},
+		},
+		{
+			name: "pods younger than the interval are not kicked",
+			age:  10 * time.Minute,
+			steps: []step{
+				{fleet: 4, kicks: false},
+				{after: 6 * time.Minute, fleet: 4, kicks: true},
+			},
+		},
+	}
+
+	builds := map[string]func(Evaluator) Evaluator{
+		"SpreadFast": func(eval Evaluator) Evaluator {
+			return SpreadFast(time.Hour, 1, eval)
+		},
+		"SpreadFastFor": func(eval Evaluator) Evaluator {
+			return SpreadFastFor(time.Hour, Lookups{}, EvaluatorSeive(OlderThanShare(time.Hour), eval))
+		},
+	}
+
+	for _, tt := range tests {
+		for name, build := range builds {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				runSteps(t, build, tt.age, tt.steps)
+			})
+		}
+	}
+}
+
+func TestTopologySpreadWindowStartsOnKick(t *testing.T) {
+	l := Lookups{kicks: &[]func(pod v1.Pod, at time.Time){}}
+	eval := TopologySpreadFor("zone", 1, 0, time.Hour, l)
+
+	pod := runningPod("default", "web-1")
+	pod.Spec.NodeName = "node-1"
+	for i := 0; i < 2; i++ {
+		if got := eval([]v1.Pod{pod}); len(got) != 1 {
+			t.Fatalf("evaluation %d selected %v without a kick, want web-1", i, podNames(got))
+		}
+	}
+
+	l.kicked(pod, time.Now())
+	if got := eval([]v1.Pod{pod}); len(got) != 0 {
+		t.Errorf("selected %v after a kick on the node within the window, want nothing", podNames(got))
+	}
+}
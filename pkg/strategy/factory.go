@@ -1,9 +1,12 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/curlymon/kicker/pkg/conf"
 	"k8s.io/api/core/v1"
@@ -11,8 +14,69 @@ import (
 
 // Strategy is an object used to statefully evaluate a set of v1.Pod for to be kicked
 type Strategy struct {
-	c    conf.Criteria
-	eval Evaluator
+	c       conf.Criteria
+	eval    ContextEvaluator
+	filter  Filter
+	lookups Lookups
+
+	// evalMu serializes evaluations with the kicks recorded into the state of the evaluator
+	evalMu sync.Mutex
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// Status describes the outcome of the most recent evaluations of a Strategy
+type Status struct {
+	// LastEvaluation is when the Strategy was last evaluated
+	LastEvaluation time.Time `json:"lastEvaluation"`
+
+	// Candidates is the number of pods matched by the conf.Criteria at the last evaluation
+	Candidates int `json:"candidates"`
+
+	// Selected is the number of pods selected for kicking at the last evaluation
+	Selected int `json:"selected"`
+
+	// LastSelection is when the Strategy last selected any pods for kicking. Selected pods may still be refused by the
+	// engine's safeguards, so this is not necessarily a kick.
+	LastSelection time.Time `json:"lastSelection"`
+
+	// CoolDownUntil is when the conf.Criteria.CoolDown started by LastKick expires
+	CoolDownUntil time.Time `json:"coolDownUntil"`
+
+	// LastKick is when a pod selected by the Strategy was last kicked, or would have been in dry run mode
+	LastKick time.Time `json:"lastKick"`
+
+	// LastError is the error of the last evaluation, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Status returns the Status of this Strategy. It is safe to call concurrently with Evaluate.
+func (s *Strategy) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// RecordKick records that the passed pod, selected by the last evaluation of this Strategy, was kicked at the passed
+// time. Cool downs and other time windows of the evaluator start here rather than when pods are selected, so pods
+// refused by the engine's safeguards do not hold back the next evaluation. It is safe to call concurrently with
+// Evaluate.
+func (s *Strategy) RecordKick(pod v1.Pod, at time.Time) {
+	s.evalMu.Lock()
+	s.lookups.kicked(pod, at)
+	s.evalMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastKick = at
+	s.status.CoolDownUntil = at.Add(time.Duration(s.c.CoolDown) * time.Second)
+}
+
+// Close releases what the evaluator of this Strategy holds, e.g. a launched plugin. The Strategy must not be evaluated
+// afterwards.
+func (s *Strategy) Close() error {
+	return s.lookups.close()
 }
 
 // Criteria return the conf.Criteria used to create this Strategy
@@ -20,33 +84,153 @@ func (s *Strategy) Criteria() conf.Criteria {
 	return s.c
 }
 
-// Evaluate performs the evaluation defined by the conf.Criteria used to create this Strategy
+// Matches reports whether the passed v1.Pod is targeted by the conf.Criteria used to create this Strategy, regardless of
+// whether it would currently be selected for kicking.
+func (s *Strategy) Matches(pod v1.Pod) bool {
+	return s.filter(pod)
+}
+
+// Evaluate performs the evaluation defined by the conf.Criteria used to create this Strategy. Errors are logged and
+// select nothing; use EvaluateContext to handle them.
 func (s *Strategy) Evaluate(pods []v1.Pod) []v1.Pod {
+	res, err := s.EvaluateContext(context.Background(), pods)
+	if err != nil {
+		log.Printf("error evaluating %s, selecting nothing: %s", s.c.Name, err)
+		return nil
+	}
+
+	return res.Selected
+}
+
+// EvaluateContext performs the evaluation defined by the conf.Criteria used to create this Strategy. If the evaluation
+// fails nothing is selected and the error is returned.
+func (s *Strategy) EvaluateContext(ctx context.Context, pods []v1.Pod) (Result, error) {
 	log.Printf("Evaluate called with %d pods", len(pods))
-	pods = s.eval(pods)
-	log.Printf("Evaluate exiting with %d pods", len(pods))
-	return pods
+	now := time.Now()
+	var candidates int
+	for i := range pods {
+		if s.filter(pods[i]) {
+			candidates++
+		}
+	}
+
+	s.evalMu.Lock()
+	res, err := s.eval.EvaluateContext(ctx, Input{
+		Pods:     pods,
+		Now:      now,
+		Criteria: s.c,
+		Lookups:  s.lookups,
+	})
+	s.evalMu.Unlock()
+	if err != nil {
+		res = Result{}
+	}
+
+	s.mu.Lock()
+	s.status.LastEvaluation = now
+	s.status.Candidates = candidates
+	s.status.Selected = len(res.Selected)
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	if len(res.Selected) > 0 {
+		s.status.LastSelection = now
+	}
+	s.mu.Unlock()
+
+	logReasons(s.c, res)
+	log.Printf("Evaluate exiting with %d pods", len(res.Selected))
+	return res, err
 }
 
 // NewStrategy builds and returns a new Strategy for the provided conf.Criteria, returning an error if unable to do so.
-func NewStrategy(c conf.Criteria) (*Strategy, error) {
-	stratCon, err := RetrieveEvaluatorConstructor(c.Strategy)
+// The Lookups are passed on to the EvaluatorConstructor and used for conf.Criteria.Triggers. conf.Criteria.Triggers and
+// conf.Criteria.Topology are applied by LimitFor within the strategy, so limits and spread intervals are sized from
+// every matched pod; pods that were not triggered are never selected.
+// The pipeline strategy is built from its stages rather than a registered constructor.
+// The Strategy must be closed with Close once it is no longer used.
+func NewStrategy(c conf.Criteria, lookups Lookups) (*Strategy, error) {
+	filter, err := NewCriteriaFilter(c)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	lookups.closers = &[]io.Closer{}
+	lookups.kicks = &[]func(pod v1.Pod, at time.Time){}
+	var eval ContextEvaluator
+	if c.Strategy == conf.StrategyPipeline {
+		pipeline, err := Pipeline(c, lookups)
+		if err != nil {
+			// stages built before the failing one may have registered closers
+			lookups.close()
+			return nil, err
+		}
+
+		eval = FromEvaluator(pipeline)
+	} else {
+		stratCon, err := RetrieveContextEvaluatorConstructor(c.Strategy)
+		if err != nil {
+			return nil, err
+		}
+
+		eval = stratCon(c, lookups)
+	}
+
+	if c.Triggers.Enabled() {
+		eval = withTriggers(c, lookups.Triggered, eval)
 	}
 
 	return &Strategy{
-		c:    c,
-		eval: stratCon(c),
+		c:       c,
+		eval:    eval,
+		filter:  filter,
+		lookups: lookups,
 	}, nil
 }
 
+// withTriggers keeps the pods selected by eval that the triggers of c fired for, adding why they fired to their
+// reasons. LimitFor already drops pods that were not triggered; this covers evaluators that do not use it.
+func withTriggers(c conf.Criteria, triggered TriggerLookup, eval ContextEvaluator) ContextEvaluator {
+	return ContextEvaluatorFunc(func(ctx context.Context, in Input) (Result, error) {
+		res, err := eval.EvaluateContext(ctx, in)
+		if err != nil || len(res.Selected) <= 0 || triggered == nil {
+			return Result{}, err
+		}
+
+		out := Result{Selected: make([]v1.Pod, 0, len(res.Selected)), Reasons: map[string]string{}}
+		for _, pod := range res.Selected {
+			reason, fired := triggered(c.Name, pod)
+			if !fired {
+				continue
+			}
+
+			out.Selected = append(out.Selected, pod)
+			if prev := res.Reason(pod); prev != "" && reason != "" {
+				reason = prev + "; " + reason
+			} else if prev != "" {
+				reason = prev
+			}
+
+			if reason != "" {
+				out.Reasons[pod.Namespace+"/"+pod.Name] = reason
+			}
+		}
+
+		return out, nil
+	})
+}
+
 // NewGroup is a convenience function to create a group of strategies in a single call
-func NewGroup(cs []conf.Criteria) ([]*Strategy, error) {
+func NewGroup(cs []conf.Criteria, lookups Lookups) ([]*Strategy, error) {
 	strats := make([]*Strategy, 0, len(cs))
 	for i := range cs {
-		strat, err := NewStrategy(cs[i])
+		strat, err := NewStrategy(cs[i], lookups)
 		if err != nil {
+			for _, built := range strats {
+				built.Close()
+			}
+
 			return nil, err
 		}
 
@@ -62,15 +246,28 @@ type Evaluator func([]v1.Pod) []v1.Pod
 // EvaluatorConstructor defines a constructor function for an Evaluator
 type EvaluatorConstructor func(conf.Criteria) Evaluator
 
+// LookupEvaluatorConstructor defines a constructor function for an Evaluator that uses the cluster information of
+// Lookups, e.g. to resolve percentage limits with LimitFor
+type LookupEvaluatorConstructor func(conf.Criteria, Lookups) Evaluator
+
 var strategyRegistry = map[conf.Strategy]EvaluatorConstructor{}
+var lookupRegistry = map[conf.Strategy]LookupEvaluatorConstructor{}
 var mu = &sync.RWMutex{}
 
+// registered reports whether a constructor of any kind is registered for the passed name. mu must be held.
+func registered(strat conf.Strategy) bool {
+	_, evaluator := strategyRegistry[strat]
+	_, lookup := lookupRegistry[strat]
+	_, contextual := contextRegistry[strat]
+	return evaluator || lookup || contextual
+}
+
 // RegisterEvaluatorConstructor registers an EvaluatorConstructor for use with a given name. This can then be referenced
 // from a conf.Criteria.Strategy for use.
 func RegisterEvaluatorConstructor(strat conf.Strategy, con EvaluatorConstructor) error {
 	mu.Lock()
 	defer mu.Unlock()
-	if _, ok := strategyRegistry[strat]; ok {
+	if registered(strat) {
 		return fmt.Errorf("strategy '%s' is already registered", strat)
 	}
 
@@ -79,6 +276,41 @@ func RegisterEvaluatorConstructor(strat conf.Strategy, con EvaluatorConstructor)
 	return nil
 }
 
+// RegisterLookupEvaluatorConstructor registers a LookupEvaluatorConstructor for use with a given name. This can then
+// be referenced from a conf.Criteria.Strategy for use. A name may only be registered once across all kinds of
+// constructors.
+func RegisterLookupEvaluatorConstructor(strat conf.Strategy, con LookupEvaluatorConstructor) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if registered(strat) {
+		return fmt.Errorf("strategy '%s' is already registered", strat)
+	}
+
+	lookupRegistry[strat] = con
+
+	return nil
+}
+
+// RetrieveLookupEvaluatorConstructor retrieves a registered LookupEvaluatorConstructor. Strategies registered with
+// RegisterEvaluatorConstructor are adapted to ignore the Lookups.
+func RetrieveLookupEvaluatorConstructor(strat conf.Strategy) (LookupEvaluatorConstructor, error) {
+	mu.RLock()
+	con, ok := lookupRegistry[strat]
+	mu.RUnlock()
+	if ok {
+		return con, nil
+	}
+
+	stratCon, err := RetrieveEvaluatorConstructor(strat)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c conf.Criteria, _ Lookups) Evaluator {
+		return stratCon(c)
+	}, nil
+}
+
 // RetrieveEvaluatorConstructor retrieves a registered EvaluatorConstructor.
 func RetrieveEvaluatorConstructor(strat conf.Strategy) (EvaluatorConstructor, error) {
 	mu.RLock()
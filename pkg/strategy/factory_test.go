@@ -0,0 +1,159 @@
+package strategy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// closer counts how often it was closed.
+type closer struct {
+	closed int
+}
+
+func (c *closer) Close() error {
+	c.closed++
+	return nil
+}
+
+// closing is closed by the testClosing strategy and the testClosing stage.
+var closing = &closer{}
+
+func init() {
+	RegisterLookupEvaluatorConstructor("testClosing", func(c conf.Criteria, l Lookups) Evaluator {
+		l.OnClose(closing)
+		return func(pods []v1.Pod) []v1.Pod {
+			return pods
+		}
+	})
+
+	RegisterLookupEvaluatorConstructor("testCoolDown", func(c conf.Criteria, l Lookups) Evaluator {
+		return CoolDownFor(10*time.Minute, l, Limit(1))
+	})
+
+	RegisterLookupEvaluatorConstructor("testLimited", func(c conf.Criteria, l Lookups) Evaluator {
+		return EvaluatorSeive(ApplyFilter(CriteriaFilter(c)), LimitFor(c, l, func(pods []v1.Pod) []v1.Pod {
+			return pods
+		}))
+	})
+
+	RegisterStageConstructor("testClosing", Step(func(c conf.Criteria, l Lookups, p StageParams) (Evaluator, error) {
+		l.OnClose(closing)
+		return func(pods []v1.Pod) []v1.Pod {
+			return pods
+		}, nil
+	}))
+}
+
+func TestStrategyClose(t *testing.T) {
+	closing.closed = 0
+	c := pipelineCriteria()
+	c.Strategy = "testClosing"
+	strat, err := NewStrategy(c, Lookups{})
+	if err != nil {
+		t.Fatalf("NewStrategy() error = %s", err)
+	}
+
+	if closing.closed != 0 {
+		t.Fatalf("closed %d times before the Strategy was closed", closing.closed)
+	}
+
+	strat.Close()
+	strat.Close()
+	if closing.closed != 1 {
+		t.Errorf("closed %d times, want 1", closing.closed)
+	}
+}
+
+func TestNewStrategyClosesOnError(t *testing.T) {
+	closing.closed = 0
+	_, err := NewStrategy(pipelineCriteria(conf.Stage{Stage: "testMissing"}, conf.Stage{Stage: "testClosing"}), Lookups{})
+	if err == nil {
+		t.Fatalf("NewStrategy() returned no error")
+	}
+
+	if closing.closed != 1 {
+		t.Errorf("closed %d times, want the stages built before the error closed once", closing.closed)
+	}
+}
+
+func TestOnCloseOutsideNewStrategy(t *testing.T) {
+	// constructors may be called directly, e.g. in tests, with Lookups that track nothing
+	Lookups{}.OnClose(&closer{})
+}
+
+func TestCoolDownStartsOnKick(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock, restore := useFakeClock(start)
+	defer restore()
+
+	c := pipelineCriteria()
+	c.Strategy = "testCoolDown"
+	c.CoolDown = 600
+	strat, err := NewStrategy(c, Lookups{})
+	if err != nil {
+		t.Fatalf("NewStrategy() error = %s", err)
+	}
+	defer strat.Close()
+
+	fleet := fleetOf(2, time.Now())
+	for i := 0; i < 2; i++ {
+		if got := podNames(strat.Evaluate(fleet)); !reflect.DeepEqual(got, []string{"web-1"}) {
+			t.Fatalf("evaluation %d selected %v without a kick, want web-1", i, got)
+		}
+	}
+
+	strat.RecordKick(fleet[1], timeNow())
+	if got := strat.Evaluate(fleet); len(got) != 1 {
+		t.Fatalf("selected %v after kicking a pod that was not selected, want web-1", podNames(got))
+	}
+
+	strat.RecordKick(fleet[0], timeNow())
+	if got := strat.Evaluate(fleet); len(got) != 0 {
+		t.Errorf("selected %v during the cool down, want nothing", podNames(got))
+	}
+
+	if until := strat.Status().CoolDownUntil; !until.Equal(start.Add(10 * time.Minute)) {
+		t.Errorf("CoolDownUntil = %s, want %s", until, start.Add(10*time.Minute))
+	}
+
+	clock.advance(10 * time.Minute)
+	if got := strat.Evaluate(fleet); len(got) != 1 {
+		t.Errorf("selected %v after the cool down, want web-1", podNames(got))
+	}
+}
+
+func TestPercentageLimitOfTriggeredPods(t *testing.T) {
+	c := pipelineCriteria()
+	c.Strategy = "testLimited"
+	c.Limit = conf.Limit{Percent: 50}
+	c.Triggers = conf.Triggers{Probe: &conf.ProbeTrigger{}}
+
+	fired := map[string]bool{"web-2": true, "web-4": true, "web-6": true, "web-8": true, "web-9": true, "web-10": true}
+	strat, err := NewStrategy(c, Lookups{Triggered: func(criteria string, pod v1.Pod) (string, bool) {
+		return "probe failed", fired[pod.Name]
+	}})
+	if err != nil {
+		t.Fatalf("NewStrategy() error = %s", err)
+	}
+	defer strat.Close()
+
+	fleet := fleetOf(10, time.Now())
+
+	// the limit is 50% of the 10 matched pods, not of the 6 triggered ones
+	want := []string{"web-2", "web-4", "web-6", "web-8", "web-9"}
+	res, err := strat.EvaluateContext(context.Background(), fleet)
+	if got := podNames(res.Selected); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("EvaluateContext() = %v, %v, want %v", got, err, want)
+	}
+
+	for _, pod := range res.Selected {
+		if reason := res.Reason(pod); reason != "probe failed" {
+			t.Errorf("reason of %s = '%s', want the reason the triggers fired", pod.Name, reason)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package strategy
 
 import (
+	"log"
 	"strings"
 
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/expr"
 	"k8s.io/api/core/v1"
 )
 
@@ -10,6 +13,75 @@ import (
 // own strategies
 type Filter func(v1.Pod) bool
 
+// CriteriaFilter matches pods targeted by the passed conf.Criteria by name prefix, namespace, the state of its
+// conf.Criteria.Kind and its conf.Criteria.Expression. Unless the Kind targets terminating pods, pods that are already
+// terminating are excluded so that they neither get kicked again nor count towards the fleet of a spread strategy.
+// This is the filter every built in strategy applies before evaluation. If the expression does not compile nothing
+// matches; NewStrategy reports such criteria as an error before any strategy is built.
+func CriteriaFilter(c conf.Criteria) Filter {
+	filter, err := NewCriteriaFilter(c)
+	if err != nil {
+		log.Printf("Criteria %s: %s, matching no pods", c.Name, err)
+		return func(v1.Pod) bool {
+			return false
+		}
+	}
+
+	return filter
+}
+
+// NewCriteriaFilter is like CriteriaFilter but returns an error if conf.Criteria.Expression does not compile.
+func NewCriteriaFilter(c conf.Criteria) (Filter, error) {
+	filters := []Filter{
+		NamePrefixFilter(c.Name),
+		NameSpaceFilter(c.Namespace),
+		KindFilter(c.Kind),
+	}
+
+	if c.Expression != "" {
+		prg, err := expr.Compile(c.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, ExpressionFilter(prg))
+	}
+
+	return And(filters...), nil
+}
+
+// ExpressionFilter matches when the passed compiled expression evaluates to true for the passed v1.Pod
+func ExpressionFilter(p *expr.Program) Filter {
+	return p.Matches
+}
+
+// KindFilter matches when the passed v1.Pod is in the state targeted by the passed conf.Kind
+func KindFilter(kind conf.Kind) Filter {
+	switch kind {
+	case conf.KindTerminating:
+		return TerminatingFilter
+	case conf.KindFailed:
+		return And(StatusFilter(v1.PodFailed), Not(TerminatingFilter))
+	case conf.KindSucceeded:
+		return And(StatusFilter(v1.PodSucceeded), Not(TerminatingFilter))
+	default:
+		return And(StatusFilter(v1.PodRunning), Not(TerminatingFilter))
+	}
+}
+
+// TriggeredFilter matches when the triggers of the passed conf.Criteria have fired for the passed v1.Pod. Nothing
+// matches if triggered is nil.
+func TriggeredFilter(c conf.Criteria, triggered TriggerLookup) Filter {
+	return func(p v1.Pod) bool {
+		if triggered == nil {
+			return false
+		}
+
+		_, fired := triggered(c.Name, p)
+		return fired
+	}
+}
+
 // NameSpaceFilter matches when the passed v1.Pod.Namespace is equivalent to the passed namespace
 func NameSpaceFilter(namespace string) Filter {
 	return func(p v1.Pod) bool {
@@ -31,10 +103,15 @@ func StatusFilter(status v1.PodPhase) Filter {
 	}
 }
 
+// TerminatingFilter matches when the passed v1.Pod has been marked for deletion
+func TerminatingFilter(p v1.Pod) bool {
+	return p.DeletionTimestamp != nil
+}
+
 // Not inverts the result of the passed Filter
 func Not(filter Filter) Filter {
 	return func(p v1.Pod) bool {
-		return filter(p)
+		return !filter(p)
 	}
 }
 
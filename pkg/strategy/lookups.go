@@ -0,0 +1,144 @@
+package strategy
+
+import (
+	"io"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+// Lookups provides evaluators with cluster information beyond the pods they are passed. Any lookup may be nil, in which
+// case evaluators fall back to what can be derived from the pods alone.
+type Lookups struct {
+	// Nodes resolves node labels, used for topology aware evaluation
+	Nodes NodeLabeler
+
+	// Replicas resolves the desired replicas of a pod's owner, used for percentage limits relative to replicas
+	Replicas ReplicaCounter
+
+	// Triggered resolves whether a criteria's triggers have fired for a pod, used when conf.Criteria.Triggers is set
+	Triggered TriggerLookup
+
+	// closers are closed with the Strategy built by NewStrategy with these Lookups
+	closers *[]io.Closer
+
+	// kicks are called with the pods kicked for the Strategy built by NewStrategy with these Lookups
+	kicks *[]func(pod v1.Pod, at time.Time)
+}
+
+// OnClose registers c to be closed when the Strategy built with these Lookups is closed. Constructors use it to release
+// what their evaluators hold, e.g. a launched plugin. It does nothing for Lookups not passed by NewStrategy.
+func (l Lookups) OnClose(c io.Closer) {
+	if l.closers != nil {
+		*l.closers = append(*l.closers, c)
+	}
+}
+
+// close closes everything registered with OnClose, returning the first error.
+func (l Lookups) close() error {
+	if l.closers == nil {
+		return nil
+	}
+
+	var first error
+	for _, c := range *l.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	*l.closers = nil
+	return first
+}
+
+// OnKick registers f to be called with every pod kicked for the Strategy built with these Lookups, see
+// Strategy.RecordKick. Evaluators use it to start time windows, e.g. a cool down, only once their selection is acted on
+// rather than when a safeguard of the engine may still refuse it. It does nothing and returns false for Lookups not
+// passed by NewStrategy.
+func (l Lookups) OnKick(f func(pod v1.Pod, at time.Time)) bool {
+	if l.kicks == nil {
+		return false
+	}
+
+	*l.kicks = append(*l.kicks, f)
+	return true
+}
+
+// kicked calls everything registered with OnKick.
+func (l Lookups) kicked(pod v1.Pod, at time.Time) {
+	if l.kicks == nil {
+		return
+	}
+
+	for _, f := range *l.kicks {
+		f(pod, at)
+	}
+}
+
+// kickCommit starts the window of a time based evaluator once a pod it selected is kicked. Without OnKick, e.g. for an
+// evaluator used outside of a Strategy, windows start as soon as pods are selected.
+type kickCommit struct {
+	commit   func(pod v1.Pod, at time.Time)
+	hooked   bool
+	selected map[string]bool
+}
+
+func newKickCommit(l Lookups, commit func(pod v1.Pod, at time.Time)) *kickCommit {
+	k := &kickCommit{commit: commit}
+	k.hooked = l.OnKick(k.kicked)
+	return k
+}
+
+// record keeps the pods selected by the evaluator at now, replacing those of its previous evaluation.
+func (k *kickCommit) record(pods []v1.Pod, now time.Time) {
+	if !k.hooked {
+		for _, pod := range pods {
+			k.commit(pod, now)
+		}
+
+		return
+	}
+
+	k.selected = make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		k.selected[pod.Namespace+"/"+pod.Name] = true
+	}
+}
+
+func (k *kickCommit) kicked(pod v1.Pod, at time.Time) {
+	key := pod.Namespace + "/" + pod.Name
+	if k.selected[key] {
+		delete(k.selected, key)
+		k.commit(pod, at)
+	}
+}
+
+// TriggerLookup reports whether the triggers of the named criteria fired for the passed pod in the current evaluation,
+// and why.
+type TriggerLookup func(criteria string, pod v1.Pod) (reason string, fired bool)
+
+// ReplicaCounter returns a key identifying the owner of the passed pod that defines its desired replica count, and that
+// count. False is returned if the pod has no such owner.
+type ReplicaCounter func(pod v1.Pod) (owner string, replicas int64, ok bool)
+
+// desiredReplicas sums the desired replicas of the distinct owners of the passed pods. Pods without a known owner each
+// count as a single replica.
+func desiredReplicas(pods []v1.Pod, replicas ReplicaCounter) int {
+	owners := map[string]int64{}
+	var total int64
+	for _, pod := range pods {
+		owner, n, ok := replicas(pod)
+		if !ok {
+			total++
+			continue
+		}
+
+		owners[owner] = n
+	}
+
+	for _, n := range owners {
+		total += n
+	}
+
+	return int(total)
+}
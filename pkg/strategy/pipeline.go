@@ -0,0 +1,211 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// StageParams are the parameters of a pipeline stage or filter as given in the config.
+type StageParams map[string]string
+
+// String returns the named parameter, or def if it is not set.
+func (p StageParams) String(key, def string) string {
+	if v, ok := p[key]; ok {
+		return v
+	}
+
+	return def
+}
+
+// Required returns the named parameter, or an error if it is not set.
+func (p StageParams) Required(key string) (string, error) {
+	v, ok := p[key]
+	if !ok || v == "" {
+		return "", fmt.Errorf("param %s is required", key)
+	}
+
+	return v, nil
+}
+
+// Int returns the named parameter as an integer, or def if it is not set.
+func (p StageParams) Int(key string, def int64) (int64, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("param %s '%s' is not an integer", key, v)
+	}
+
+	return i, nil
+}
+
+// Float returns the named parameter as a float, or def if it is not set.
+func (p StageParams) Float(key string, def float64) (float64, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("param %s '%s' is not a number", key, v)
+	}
+
+	return f, nil
+}
+
+// Duration returns the named parameter as a duration, or def if it is not set. Durations are given either as a number
+// of seconds or as a Go duration, e.g. "10m".
+func (p StageParams) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("param %s '%s' is not a duration", key, v)
+	}
+
+	return d, nil
+}
+
+// StageConstructor builds a pipeline stage. next is the Evaluator made of every stage that follows it in the pipeline;
+// the stage decides how to call it, e.g. after narrowing the pods or only outside of a cool down.
+type StageConstructor func(c conf.Criteria, l Lookups, params StageParams, next Evaluator) (Evaluator, error)
+
+// FilterConstructor builds a Filter usable as a pipeline stage.
+type FilterConstructor func(c conf.Criteria, l Lookups, params StageParams) (Filter, error)
+
+// Step adapts a constructor of a plain Evaluator into a StageConstructor that runs the Evaluator, then the stages that
+// follow it.
+func Step(build func(c conf.Criteria, l Lookups, params StageParams) (Evaluator, error)) StageConstructor {
+	return func(c conf.Criteria, l Lookups, params StageParams, next Evaluator) (Evaluator, error) {
+		eval, err := build(c, l, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return EvaluatorSeive(eval, next), nil
+	}
+}
+
+var stageRegistry = map[string]StageConstructor{}
+var filterRegistry = map[string]FilterConstructor{}
+
+// RegisterStageConstructor registers a StageConstructor for use with a given name. This can then be referenced from a
+// conf.Stage.Stage for use.
+func RegisterStageConstructor(name string, con StageConstructor) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := stageRegistry[name]; ok {
+		return fmt.Errorf("stage '%s' is already registered", name)
+	}
+
+	stageRegistry[name] = con
+
+	return nil
+}
+
+// RetrieveStageConstructor retrieves a registered StageConstructor.
+func RetrieveStageConstructor(name string) (StageConstructor, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	con, ok := stageRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("stage '%s' is not registered for use", name)
+	}
+
+	return con, nil
+}
+
+// RegisterFilterConstructor registers a FilterConstructor for use with a given name. This can then be referenced from a
+// conf.Stage.Filter for use.
+func RegisterFilterConstructor(name string, con FilterConstructor) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := filterRegistry[name]; ok {
+		return fmt.Errorf("filter '%s' is already registered", name)
+	}
+
+	filterRegistry[name] = con
+
+	return nil
+}
+
+// RetrieveFilterConstructor retrieves a registered FilterConstructor.
+func RetrieveFilterConstructor(name string) (FilterConstructor, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	con, ok := filterRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("filter '%s' is not registered for use", name)
+	}
+
+	return con, nil
+}
+
+// Pipeline builds the Evaluator of the pipeline strategy from conf.Criteria.Pipeline. Pods are restricted to those
+// matched by CriteriaFilter before the first stage so that a pipeline cannot reach beyond its criteria. Like the built in
+// strategies, the stages are wrapped in LimitFor and a cool down of conf.Criteria.CoolDown, so a pipeline of filters
+// alone does not kick every matching pod each evaluation; stages can only narrow the selection further.
+func Pipeline(c conf.Criteria, l Lookups) (Evaluator, error) {
+	next := Evaluator(func(pods []v1.Pod) []v1.Pod {
+		return pods
+	})
+
+	for i := len(c.Pipeline) - 1; i >= 0; i-- {
+		s := c.Pipeline[i]
+		params := StageParams(s.Params)
+		if s.Filter != "" {
+			con, err := RetrieveFilterConstructor(s.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("Criteria %s: pipeline stage %d: %s", c.Name, i+1, err)
+			}
+
+			filter, err := con(c, l, params)
+			if err != nil {
+				return nil, fmt.Errorf("Criteria %s: pipeline filter %d %s: %s", c.Name, i+1, s.Filter, err)
+			}
+
+			if s.Not {
+				filter = Not(filter)
+			}
+
+			next = EvaluatorSeive(ApplyFilter(filter), next)
+			continue
+		}
+
+		con, err := RetrieveStageConstructor(s.Stage)
+		if err != nil {
+			return nil, fmt.Errorf("Criteria %s: pipeline stage %d: %s", c.Name, i+1, err)
+		}
+
+		if next, err = con(c, l, params, next); err != nil {
+			return nil, fmt.Errorf("Criteria %s: pipeline stage %d %s: %s", c.Name, i+1, s.Stage, err)
+		}
+	}
+
+	filter, err := NewCriteriaFilter(c)
+	if err != nil {
+		return nil, fmt.Errorf("Criteria %s: %s", c.Name, err)
+	}
+
+	prefilter := EvaluatorSeive(
+		ApplyFilter(filter),
+		LimitFor(c, l, next),
+	)
+
+	return CoolDownFor(time.Duration(c.CoolDown)*time.Second, l, prefilter), nil
+}
@@ -0,0 +1,304 @@
+package strategy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// trace holds the names of the testTrace stages in the order they were called
+var trace []string
+
+// traced holds the names of the pods passed to each testTrace stage
+var traced = map[string][]string{}
+
+func init() {
+	// testTrace records that it was called, then runs the stages that follow it
+	RegisterStageConstructor("testTrace", Step(func(c conf.Criteria, l Lookups, p StageParams) (Evaluator, error) {
+		name, err := p.Required("name")
+		if err != nil {
+			return nil, err
+		}
+
+		return func(pods []v1.Pod) []v1.Pod {
+			trace = append(trace, name)
+			traced[name] = podNames(pods)
+			return pods
+		}, nil
+	}))
+
+	// testGate wraps the stages that follow it, only running them when param open is true
+	RegisterStageConstructor("testGate", func(c conf.Criteria, l Lookups, p StageParams, next Evaluator) (Evaluator, error) {
+		open := p.String("open", "false") == "true"
+		return func(pods []v1.Pod) []v1.Pod {
+			if !open {
+				return nil
+			}
+
+			return next(pods)
+		}, nil
+	})
+
+	// testName matches the pod named by param name
+	RegisterFilterConstructor("testName", func(c conf.Criteria, l Lookups, p StageParams) (Filter, error) {
+		name, err := p.Required("name")
+		if err != nil {
+			return nil, err
+		}
+
+		return func(pod v1.Pod) bool {
+			return pod.Name == name
+		}, nil
+	})
+}
+
+func resetTrace() {
+	trace = nil
+	traced = map[string][]string{}
+}
+
+func pipelineCriteria(stages ...conf.Stage) conf.Criteria {
+	return conf.Criteria{
+		Name:      "web",
+		Namespace: "default",
+		Kind:      conf.KindRunning,
+		Limit:     conf.Limit{Count: 10},
+		Strategy:  conf.StrategyPipeline,
+		Pipeline:  stages,
+	}
+}
+
+func traceStage(name string) conf.Stage {
+	return conf.Stage{Stage: "testTrace", Params: map[string]string{"name": name}}
+}
+
+func TestPipeline(t *testing.T) {
+	fleet := fleetOf(3, time.Now())
+	tests := []struct {
+		name     string
+		stages   []conf.Stage
+		selected []string
+		trace    []string
+	}{
+		{
+			name:     "empty pipeline",
+			selected: []string{"web-1", "web-2", "web-3"},
+		},
+		{
+			name:     "stages run in order",
+			stages:   []conf.Stage{traceStage("a"), traceStage("b"), traceStage("c")},
+			selected: []string{"web-1", "web-2", "web-3"},
+			trace:    []string{"a", "b", "c"},
+		},
+		{
+			name: "wrapping stage runs what follows it",
+			stages: []conf.Stage{
+				traceStage("a"),
+				{Stage: "testGate", Params: map[string]string{"open": "true"}},
+				traceStage("b"),
+			},
+			selected: []string{"web-1", "web-2", "web-3"},
+			trace:    []string{"a", "b"},
+		},
+		{
+			name: "wrapping stage skips what follows it",
+			stages: []conf.Stage{
+				traceStage("a"),
+				{Stage: "testGate"},
+				traceStage("b"),
+				traceStage("c"),
+			},
+			trace: []string{"a"},
+		},
+		{
+			name: "filter",
+			stages: []conf.Stage{
+				{Filter: "testName", Params: map[string]string{"name": "web-2"}},
+				traceStage("a"),
+			},
+			selected: []string{"web-2"},
+			trace:    []string{"a"},
+		},
+		{
+			name: "not filter",
+			stages: []conf.Stage{
+				{Filter: "testName", Not: true, Params: map[string]string{"name": "web-2"}},
+				traceStage("a"),
+			},
+			selected: []string{"web-1", "web-3"},
+			trace:    []string{"a"},
+		},
+		{
+			name: "not is ignored for stages",
+			stages: []conf.Stage{
+				{Stage: "testTrace", Not: true, Params: map[string]string{"name": "a"}},
+			},
+			selected: []string{"web-1", "web-2", "web-3"},
+			trace:    []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTrace()
+			eval, err := Pipeline(pipelineCriteria(tt.stages...), Lookups{})
+			if err != nil {
+				t.Fatalf("Pipeline() error = %s", err)
+			}
+
+			if got := podNames(eval(fleet)); !reflect.DeepEqual(got, append([]string{}, tt.selected...)) {
+				t.Errorf("selected = %v, want %v", got, tt.selected)
+			}
+
+			if !reflect.DeepEqual(trace, tt.trace) {
+				t.Errorf("trace = %v, want %v", trace, tt.trace)
+			}
+		})
+	}
+}
+
+func TestPipelineAppliesCriteriaFilterFirst(t *testing.T) {
+	pending := runningPod("default", "web-pending")
+	pending.Status.Phase = v1.PodPending
+	terminating := runningPod("default", "web-terminating")
+	terminating.DeletionTimestamp = &metav1.Time{}
+	pods := []v1.Pod{
+		runningPod("default", "web-1"),
+		runningPod("default", "api-1"),
+		runningPod("other", "web-1"),
+		pending,
+		terminating,
+	}
+
+	resetTrace()
+	eval, err := Pipeline(pipelineCriteria(
+		conf.Stage{Filter: "testName", Not: true, Params: map[string]string{"name": "web-2"}},
+		traceStage("a"),
+	), Lookups{})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %s", err)
+	}
+
+	got := eval(pods)
+	if len(got) != 1 || got[0].Namespace != "default" || got[0].Name != "web-1" {
+		t.Errorf("selected = %v, want only default/web-1", podNames(got))
+	}
+
+	if want := []string{"web-1"}; !reflect.DeepEqual(traced["a"], want) {
+		t.Errorf("first stage was passed %v, want %v", traced["a"], want)
+	}
+}
+
+func TestPipelineLimitsAndCoolsDown(t *testing.T) {
+	c := pipelineCriteria(traceStage("a"))
+	c.Limit = conf.Limit{Count: 1}
+	c.CoolDown = 600
+	eval, err := Pipeline(c, Lookups{})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %s", err)
+	}
+
+	fleet := fleetOf(2, time.Now())
+	if got := podNames(eval(fleet)); !reflect.DeepEqual(got, []string{"web-1"}) {
+		t.Fatalf("selected %v, want only web-1 within the limit", got)
+	}
+
+	if got := eval(fleet); len(got) != 0 {
+		t.Errorf("selected %v during the cool down, want nothing", podNames(got))
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []conf.Stage
+		err    string
+	}{
+		{
+			name:   "unknown stage",
+			stages: []conf.Stage{traceStage("a"), {Stage: "testMissing"}},
+			err:    "Criteria web: pipeline stage 2: stage 'testMissing' is not registered for use",
+		},
+		{
+			name:   "unknown filter",
+			stages: []conf.Stage{{Filter: "testMissing"}},
+			err:    "Criteria web: pipeline stage 1: filter 'testMissing' is not registered for use",
+		},
+		{
+			name:   "stage params",
+			stages: []conf.Stage{traceStage("a"), {Stage: "testTrace"}},
+			err:    "Criteria web: pipeline stage 2 testTrace: param name is required",
+		},
+		{
+			name:   "filter params",
+			stages: []conf.Stage{{Filter: "testName", Not: true}},
+			err:    "Criteria web: pipeline filter 1 testName: param name is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Pipeline(pipelineCriteria(tt.stages...), Lookups{})
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Pipeline() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestStageParams(t *testing.T) {
+	p := StageParams{
+		"count":    "3",
+		"ratio":    "0.5",
+		"seconds":  "90",
+		"duration": "10m",
+		"bad":      "ten",
+	}
+
+	if got, err := p.Int("count", 1); err != nil || got != 3 {
+		t.Errorf("Int(count) = %d, %v, want 3", got, err)
+	}
+
+	if got, err := p.Int("missing", 1); err != nil || got != 1 {
+		t.Errorf("Int(missing) = %d, %v, want the default 1", got, err)
+	}
+
+	if got, err := p.Float("ratio", 1); err != nil || got != 0.5 {
+		t.Errorf("Float(ratio) = %g, %v, want 0.5", got, err)
+	}
+
+	if got, err := p.Duration("seconds", 0); err != nil || got.Seconds() != 90 {
+		t.Errorf("Duration(seconds) = %s, %v, want 90s", got, err)
+	}
+
+	if got, err := p.Duration("duration", 0); err != nil || got.Minutes() != 10 {
+		t.Errorf("Duration(duration) = %s, %v, want 10m", got, err)
+	}
+
+	if _, err := p.Required("missing"); err == nil {
+		t.Errorf("Required(missing) returned no error")
+	}
+
+	for name, parse := range map[string]func() error{
+		"Int":      func() error { _, err := p.Int("bad", 0); return err },
+		"Float":    func() error { _, err := p.Float("bad", 0); return err },
+		"Duration": func() error { _, err := p.Duration("bad", 0); return err },
+	} {
+		if err := parse(); err == nil || !strings.Contains(err.Error(), "param bad 'ten'") {
+			t.Errorf("%s(bad) error = %v, want it to name the param", name, err)
+		}
+	}
+}
+
+func TestNewStrategyReturnsExpressionErrors(t *testing.T) {
+	c := pipelineCriteria(traceStage("a"))
+	c.Expression = `pod.metdata.name == "web-1"`
+	if _, err := NewStrategy(c, Lookups{}); err == nil || !strings.Contains(err.Error(), "pod has no field metdata") {
+		t.Errorf("NewStrategy() error = %v, want the expression error", err)
+	}
+}
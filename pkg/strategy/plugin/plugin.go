@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	pluginproto "github.com/curlymon/kicker/pkg/plugin"
+	"github.com/curlymon/kicker/pkg/strategy"
+	"k8s.io/api/core/v1"
+)
+
+func init() {
+	if err := strategy.RegisterContextEvaluatorConstructor(conf.StrategyPlugin, Plugin); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Plugin defines the plugin strategy evaluation.
+// It first filters the passed list of pods to the setting defined in the passed conf.Criteria.
+// Then it sends the filtered pods to the plugin configured by conf.Criteria.Plugin and keeps the pods it selects, in the
+// order it selects them, with the reasons it gives. Selections of pods that were not sent are ignored. If the plugin
+// fails, times out, panics or the evaluation is cancelled, nothing is selected and the error is returned.
+// Last it kicks pods in order until conf.Criteria.Limit, resolved against the matched pods, is reached.
+// If a pod is kicked, a cooldown for re-evaluation is triggered with a length of conf.Criteria.CoolDown.
+// The plugin is stopped, if it was launched, when the Strategy is closed.
+func Plugin(c conf.Criteria, l strategy.Lookups) strategy.ContextEvaluator {
+	client := pluginproto.NewClient(*c.Plugin)
+	l.OnClose(client)
+
+	// build a logic core that assumes filtered pods
+	core := strategy.ContextEvaluatorFunc(func(ctx context.Context, in strategy.Input) (strategy.Result, error) {
+		return evaluate(ctx, c, client, in)
+	})
+
+	// build a filter top remove all non matching and unhealthy pods
+	filter := strategy.CriteriaFilter(c)
+
+	// setup prefilter
+	prefilter := strategy.Before(
+		strategy.ApplyFilter(filter),
+		strategy.LimitForContext(c, l, core),
+	)
+
+	// wrap prefilter strategy with cooldown
+	return strategy.CoolDownForContext(time.Duration(c.CoolDown)*time.Second, l, prefilter)
+}
+
+func evaluate(ctx context.Context, c conf.Criteria, client *pluginproto.Client, in strategy.Input) (res strategy.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = strategy.Result{}, fmt.Errorf("plugin panicked: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.Plugin.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := client.Evaluate(ctx, &pluginproto.EvaluateRequest{
+		Criteria:  c.Name,
+		Namespace: c.Namespace,
+		Params:    c.Plugin.Params,
+		Now:       in.Now,
+		Pods:      in.Pods,
+	})
+	if err != nil {
+		return strategy.Result{}, fmt.Errorf("error calling plugin: %s", err)
+	}
+
+	byKey := make(map[string]v1.Pod, len(in.Pods))
+	for _, pod := range in.Pods {
+		byKey[pod.Namespace+"/"+pod.Name] = pod
+	}
+
+	res.Reasons = map[string]string{}
+	for _, s := range resp.Selected {
+		key := s.Namespace + "/" + s.Name
+		pod, ok := byKey[key]
+		if !ok {
+			log.Printf("plugin strategy %s selected unknown pod %s, ignoring it", c.Name, key)
+			continue
+		}
+
+		// a pod selected twice is only kicked once
+		delete(byKey, key)
+		res.Selected = append(res.Selected, pod)
+		if s.Reason != "" {
+			res.Reasons[key] = s.Reason
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,289 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	pluginproto "github.com/curlymon/kicker/pkg/plugin"
+	"github.com/curlymon/kicker/pkg/strategy"
+	"google.golang.org/grpc"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakePlugin answers every evaluation with evaluate.
+type fakePlugin struct {
+	evaluate func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error)
+}
+
+func (f fakePlugin) Evaluate(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+	return f.evaluate(ctx, req)
+}
+
+// serve serves p in process on a unix socket and returns its conf.Plugin.Address and the server, which must be
+// stopped.
+func serve(t *testing.T, p pluginproto.Plugin) (string, *grpc.Server) {
+	dir, err := ioutil.TempDir("", "kicker-plugin-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(dir, "plugin.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer()
+	pluginproto.Register(s, p)
+	go func() {
+		s.Serve(lis)
+		os.RemoveAll(dir)
+	}()
+
+	return pluginproto.UnixPrefix + socket, s
+}
+
+func criteria(address string) conf.Criteria {
+	return conf.Criteria{
+		Name:      "web",
+		Namespace: "default",
+		Kind:      conf.KindRunning,
+		Limit:     conf.Limit{Count: 2},
+		Strategy:  conf.StrategyPlugin,
+		Plugin: &conf.Plugin{
+			Address: address,
+			Params:  map[string]string{"mode": "conservative"},
+			Timeout: 1,
+		},
+	}
+}
+
+// fleet returns the running pods every test of this package evaluates, three matched by the criteria and one not.
+func fleet() []v1.Pod {
+	var out []v1.Pod
+	for _, name := range []string{"web-1", "web-2", "web-3", "api-1"} {
+		out = append(out, v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		})
+	}
+
+	return out
+}
+
+func podNames(pods []v1.Pod) []string {
+	out := []string{}
+	for _, pod := range pods {
+		out = append(out, pod.Name)
+	}
+
+	return out
+}
+
+// run runs the plugin strategy of c once against fleet().
+func run(ctx context.Context, c conf.Criteria) (strategy.Result, error) {
+	return Plugin(c, strategy.Lookups{}).EvaluateContext(ctx, strategy.Input{Pods: fleet(), Now: time.Now(), Criteria: c})
+}
+
+func selection(names ...string) *pluginproto.EvaluateResponse {
+	resp := &pluginproto.EvaluateResponse{}
+	for _, name := range names {
+		resp.Selected = append(resp.Selected, pluginproto.Selection{Namespace: "default", Name: name, Reason: "picked"})
+	}
+
+	return resp
+}
+
+func TestPlugin(t *testing.T) {
+	tests := []struct {
+		name     string
+		evaluate func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error)
+		selected []string
+		err      string
+	}{
+		{
+			name: "selection order",
+			evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+				return selection("web-3", "web-1"), nil
+			},
+			selected: []string{"web-3", "web-1"},
+		},
+		{
+			name: "limit",
+			evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+				return selection("web-1", "web-2", "web-3"), nil
+			},
+			selected: []string{"web-1", "web-2"},
+		},
+		{
+			name: "unknown and duplicate pods are ignored",
+			evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+				return selection("web-9", "api-1", "web-2", "web-2"), nil
+			},
+			selected: []string{"web-2"},
+		},
+		{
+			name: "plugin error",
+			evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+				return nil, errors.New("boom")
+			},
+			selected: []string{},
+			err:      "boom",
+		},
+		{
+			name: "timeout",
+			evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(10 * time.Second):
+					return selection("web-1"), nil
+				}
+			},
+			selected: []string{},
+			err:      "DeadlineExceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, s := serve(t, fakePlugin{evaluate: tt.evaluate})
+			defer s.Stop()
+
+			start := time.Now()
+			res, err := run(context.Background(), criteria(address))
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("evaluation took %s, want it bounded by the plugin timeout", elapsed)
+			}
+
+			if (err == nil) != (tt.err == "") || err != nil && !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %s", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(podNames(res.Selected), tt.selected) {
+				t.Errorf("selected = %v, want %v", podNames(res.Selected), tt.selected)
+			}
+
+			for _, pod := range res.Selected {
+				if reason := res.Reason(pod); reason != "picked" {
+					t.Errorf("reason of %s = '%s', want the reason given by the plugin", pod.Name, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestPluginRequest(t *testing.T) {
+	requests := make(chan *pluginproto.EvaluateRequest, 1)
+	address, s := serve(t, fakePlugin{evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+		requests <- req
+		return selection(), nil
+	}})
+	defer s.Stop()
+
+	run(context.Background(), criteria(address))
+	req := <-requests
+	if req.Criteria != "web" || req.Namespace != "default" || req.Params["mode"] != "conservative" {
+		t.Errorf("request = %s %s %v, want the criteria, its namespace and params", req.Criteria, req.Namespace, req.Params)
+	}
+
+	if want := []string{"web-1", "web-2", "web-3"}; !reflect.DeepEqual(podNames(req.Pods), want) {
+		t.Errorf("sent pods %v, want only the pods matched by the criteria %v", podNames(req.Pods), want)
+	}
+}
+
+func TestPluginCrash(t *testing.T) {
+	var s *grpc.Server
+	address, s := serve(t, fakePlugin{evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+		// the plugin goes away while evaluating
+		go s.Stop()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}})
+	defer s.Stop()
+
+	start := time.Now()
+	if res, err := run(context.Background(), criteria(address)); err == nil || len(res.Selected) != 0 {
+		t.Errorf("selected = %v, error = %v, want nothing and an error", podNames(res.Selected), err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("evaluation took %s, want it to fail as soon as the plugin goes away", elapsed)
+	}
+}
+
+func TestPluginUnreachable(t *testing.T) {
+	address := pluginproto.UnixPrefix + filepath.Join(os.TempDir(), "kicker-plugin-test-missing.sock")
+	if res, err := run(context.Background(), criteria(address)); err == nil || len(res.Selected) != 0 {
+		t.Errorf("selected = %v, error = %v, want nothing and an error", podNames(res.Selected), err)
+	}
+}
+
+func TestPluginCancelled(t *testing.T) {
+	started := make(chan struct{})
+	address, s := serve(t, fakePlugin{evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}})
+	defer s.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	c := criteria(address)
+	c.Plugin.Timeout = 10
+	start := time.Now()
+	if _, err := run(ctx, c); err == nil || !strings.Contains(err.Error(), "Canceled") {
+		t.Errorf("error = %v, want the cancellation", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("evaluation took %s, want it to end once cancelled", elapsed)
+	}
+}
+
+func TestPluginCoolDown(t *testing.T) {
+	fail := true
+	address, s := serve(t, fakePlugin{evaluate: func(ctx context.Context, req *pluginproto.EvaluateRequest) (*pluginproto.EvaluateResponse, error) {
+		if fail {
+			return nil, errors.New("boom")
+		}
+
+		return selection("web-1"), nil
+	}})
+	defer s.Stop()
+
+	c := criteria(address)
+	c.CoolDown = 600
+	eval := Plugin(c, strategy.Lookups{})
+	in := strategy.Input{Pods: fleet(), Now: time.Now(), Criteria: c}
+
+	// failed evaluations do not start the cool down
+	if _, err := eval.EvaluateContext(context.Background(), in); err == nil {
+		t.Fatalf("expected the first evaluation to fail")
+	}
+
+	fail = false
+	if res, err := eval.EvaluateContext(context.Background(), in); err != nil || len(res.Selected) != 1 {
+		t.Fatalf("selected = %v, error = %v, want web-1", podNames(res.Selected), err)
+	}
+
+	if res, err := eval.EvaluateContext(context.Background(), in); err != nil || len(res.Selected) != 0 {
+		t.Errorf("selected = %v, error = %v, want nothing during the cool down", podNames(res.Selected), err)
+	}
+}
@@ -0,0 +1,65 @@
+package strategy
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+// RandomPick returns an Evaluator that, with the passed probability, returns the passed pods in a random order and
+// otherwise returns no pods. When byAge is set older pods are more likely to be ordered first, in proportion to their
+// age. Combine with Limit to bound how many pods are picked.
+func RandomPick(rng *rand.Rand, probability float64, byAge bool) Evaluator {
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("RandomPick called with %d pods", len(pods))
+		if len(pods) <= 0 {
+			return nil
+		}
+
+		if rng.Float64() >= probability {
+			log.Println("RandomPick exiting early due to probability")
+			return nil
+		}
+
+		out := make([]v1.Pod, len(pods))
+		copy(out, pods)
+		if !byAge {
+			rng.Shuffle(len(out), func(i, j int) {
+				out[i], out[j] = out[j], out[i]
+			})
+		} else {
+			// weighted sampling without replacement: order by u^(1/weight) descending
+			now := time.Now()
+			keys := make([]float64, len(out))
+			for i := range out {
+				weight := now.Sub(out[i].CreationTimestamp.Time).Seconds()
+				if weight < 1 {
+					weight = 1
+				}
+
+				keys[i] = math.Pow(rng.Float64(), 1/weight)
+			}
+
+			sort.Sort(byKeyDesc{pods: out, keys: keys})
+		}
+
+		log.Printf("RandomPick exiting with %d pods", len(out))
+		return out
+	}
+}
+
+type byKeyDesc struct {
+	pods []v1.Pod
+	keys []float64
+}
+
+func (b byKeyDesc) Len() int           { return len(b.pods) }
+func (b byKeyDesc) Less(i, j int) bool { return b.keys[i] > b.keys[j] }
+func (b byKeyDesc) Swap(i, j int) {
+	b.pods[i], b.pods[j] = b.pods[j], b.pods[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
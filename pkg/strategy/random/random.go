@@ -0,0 +1,51 @@
+package random
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/strategy"
+)
+
+func init() {
+	if err := strategy.RegisterLookupEvaluatorConstructor(conf.StrategyRandom, Random); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Random defines the random strategy evaluation.
+// It first filters the passed list of pods to the setting defined in the passed conf.Criteria.
+// Then it removes pods younger than conf.Criteria.MinAge.
+// With a chance of conf.Criteria.Random.Probability it then picks up to conf.Criteria.Limit pods, resolved against the
+// matched pods, at random, either uniformly or weighted by age as set by conf.Criteria.Random.Weight.
+// If a pod is kicked, a cooldown for re-evaluation is triggered with a length of conf.Criteria.CoolDown.
+func Random(c conf.Criteria, l strategy.Lookups) strategy.Evaluator {
+	seed := c.Random.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	log.Printf("random strategy %s seeded with %d", c.Name, seed)
+	rng := rand.New(rand.NewSource(seed))
+
+	// build a logic core that assumes filtered pods
+	core := strategy.EvaluatorSeive(
+		strategy.SortCreationTimestampAsc,
+		strategy.OlderThan(time.Duration(c.MinAge)*time.Second),
+		strategy.RandomPick(rng, c.Random.Probability, c.Random.Weight == conf.RandomWeightAge),
+	)
+
+	// build a filter top remove all non matching and unhealthy pods
+	filter := strategy.CriteriaFilter(c)
+
+	// setup prefilter
+	prefilter := strategy.EvaluatorSeive(
+		strategy.ApplyFilter(filter),
+		strategy.LimitFor(c, l, core),
+	)
+
+	// wrap prefilter strategy with cooldown
+	return strategy.CoolDownFor(time.Duration(c.CoolDown)*time.Second, l, prefilter)
+}
@@ -6,11 +6,10 @@ import (
 
 	"github.com/curlymon/kicker/pkg/conf"
 	"github.com/curlymon/kicker/pkg/strategy"
-	"k8s.io/api/core/v1"
 )
 
 func init() {
-	if err := strategy.RegisterEvaluatorConstructor(conf.StrategySpread, Spread); err != nil {
+	if err := strategy.RegisterLookupEvaluatorConstructor(conf.StrategySpread, Spread); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -19,27 +18,23 @@ func init() {
 // It first filters the passed list of pods to the setting defined in the passed conf.Criteria.
 // Then it sorts pods oldest to newest by v1.Pod.CreationTimestamp.Time.
 // Last it iterativly looks at the list of pods in order and evaluates if it should be kicked; adding this to a list
-// until conf.Criteria.Limit is reached.
+// until conf.Criteria.Limit, resolved against the matched pods, is reached.
 // If a pod is kicked, a cooldown for re-evaluation is triggered with a length of conf.Criteria.CoolDown to prevent
-// scheduler thrash. An additional cooldown is triggered for (conf.Criteria.MaxAge / podCount), this cooldown is ignored
-// if the pod count is higher then the last count at pod kick
-func Spread(c conf.Criteria) strategy.Evaluator {
+// scheduler thrash. An additional cooldown is triggered for (conf.Criteria.MaxAge / podCount), recomputed from the
+// current pod count at every evaluation; this cooldown is ignored if the pod count is higher then the last count at pod
+// kick
+func Spread(c conf.Criteria, l strategy.Lookups) strategy.Evaluator {
 	// build a logic core that assumes filtered pods
 	core := strategy.EvaluatorSeive(
 		strategy.SortCreationTimestampAsc,
 		strategy.OlderThan(time.Duration(c.MaxAge)*time.Second),
-		strategy.Limit(c.Limit),
 	)
 
-	// wrap core with Spread strategy
-	spread := strategy.Spread(time.Duration(c.MaxAge)*time.Second, core)
+	// wrap the limited core with Spread strategy, so that only pods within the limit start the spread cooldown
+	spread := strategy.SpreadFor(time.Duration(c.MaxAge)*time.Second, l, strategy.LimitFor(c, l, core))
 
 	// build a filter top remove all non matching and unhealthy pods
-	filter := strategy.And(
-		strategy.NamePrefixFilter(c.Name),
-		strategy.NameSpaceFilter(c.Namespace),
-		strategy.StatusFilter(v1.PodRunning),
-	)
+	filter := strategy.CriteriaFilter(c)
 
 	// setup prefilter
 	prefilter := strategy.EvaluatorSeive(
@@ -48,5 +43,5 @@ func Spread(c conf.Criteria) strategy.Evaluator {
 	)
 
 	// wrap prefilter strategy with cooldown
-	return strategy.CoolDown(time.Duration(c.CoolDown)*time.Second, prefilter)
+	return strategy.CoolDownFor(time.Duration(c.CoolDown)*time.Second, l, prefilter)
 }
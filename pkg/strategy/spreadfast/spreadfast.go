@@ -6,11 +6,10 @@ import (
 
 	"github.com/curlymon/kicker/pkg/conf"
 	"github.com/curlymon/kicker/pkg/strategy"
-	"k8s.io/api/core/v1"
 )
 
 func init() {
-	if err := strategy.RegisterEvaluatorConstructor(conf.StrategySpreadFast, SpreadFast); err != nil {
+	if err := strategy.RegisterLookupEvaluatorConstructor(conf.StrategySpreadFast, SpreadFast); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -19,26 +18,23 @@ func init() {
 // It first filters the passed list of pods to the setting defined in the passed conf.Criteria.
 // Then it sorts pods oldest to newest by v1.Pod.CreationTimestamp.Time.
 // Last it iterativly looks at the list of pods in order and evaluates if it should be kicked; adding this to a list
-// until conf.Criteria.Limit is reached.
+// until conf.Criteria.Limit, resolved against the matched pods, is reached.
 // If a pod is kicked, a cooldown for re-evaluation is triggered with a length of conf.Criteria.CoolDown to prevent
 // scheduler thrash. An additional cooldown is triggered for (conf.Criteria.MaxAge / podCount)
-func SpreadFast(c conf.Criteria) strategy.Evaluator {
+func SpreadFast(c conf.Criteria, l strategy.Lookups) strategy.Evaluator {
 	// build a logic core that assumes filtered pods
 	core := strategy.EvaluatorSeive(
 		strategy.SortCreationTimestampAsc,
 		// strategy.OlderThan(time.Duration(c.MaxAge)*time.Second),
-		// strategy.Limit(c.Limit),
+		// only pods older than the spread interval are due, dropped before limiting so they do not use up the limit
+		strategy.OlderThanShare(time.Duration(c.MaxAge)*time.Second),
 	)
 
-	// wrap core with SpreadFast strategy
-	spread := strategy.SpreadFast(time.Duration(c.MaxAge)*time.Second, c.Limit, core)
+	// wrap the limited core with SpreadFast strategy, so that only pods within the limit start the spread cooldown
+	spread := strategy.SpreadFastFor(time.Duration(c.MaxAge)*time.Second, l, strategy.LimitFor(c, l, core))
 
 	// build a filter top remove all non matching and unhealthy pods
-	filter := strategy.And(
-		strategy.NamePrefixFilter(c.Name),
-		strategy.NameSpaceFilter(c.Namespace),
-		strategy.StatusFilter(v1.PodRunning),
-	)
+	filter := strategy.CriteriaFilter(c)
 
 	// setup prefilter
 	prefilter := strategy.EvaluatorSeive(
@@ -47,5 +43,5 @@ func SpreadFast(c conf.Criteria) strategy.Evaluator {
 	)
 
 	// wrap prefilter strategy with cooldown
-	return strategy.CoolDown(time.Duration(c.CoolDown)*time.Second, prefilter)
+	return strategy.CoolDownFor(time.Duration(c.CoolDown)*time.Second, l, prefilter)
 }
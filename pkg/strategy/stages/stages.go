@@ -0,0 +1,292 @@
+package stages
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/expr"
+	"github.com/curlymon/kicker/pkg/strategy"
+	"k8s.io/api/core/v1"
+)
+
+func init() {
+	stages := map[string]strategy.StageConstructor{
+		"sortCreation":      strategy.Step(sortCreation),
+		"sortState":         strategy.Step(sortState),
+		"olderThan":         strategy.Step(olderThan),
+		"inStateLongerThan": strategy.Step(inStateLongerThan),
+		"limit":             strategy.Step(limit),
+		"random":            strategy.Step(random),
+		"topology":          strategy.Step(topology),
+		"limitFor":          limitFor,
+		"coolDown":          coolDown,
+		"spread":            spread,
+		"spreadFast":        spreadFast,
+	}
+
+	for name, con := range stages {
+		if err := strategy.RegisterStageConstructor(name, con); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	filters := map[string]strategy.FilterConstructor{
+		"namePrefix":    namePrefix,
+		"phase":         phase,
+		"terminating":   terminating,
+		"label":         label,
+		"annotation":    annotation,
+		"triggered":     triggered,
+		"restartsAbove": restartsAbove,
+		"expression":    expression,
+	}
+
+	for name, con := range filters {
+		if err := strategy.RegisterFilterConstructor(name, con); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// sortCreation sorts pods oldest to newest by v1.Pod.CreationTimestamp.Time.
+func sortCreation(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	return strategy.SortCreationTimestampAsc, nil
+}
+
+// sortState sorts pods by how long they have been in the state of conf.Criteria.Kind, longest first.
+func sortState(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	return strategy.SortStateSinceAsc(c.Kind), nil
+}
+
+// olderThan keeps pods older than the age param, conf.Criteria.MaxAge by default. Cleanup Kinds have no MaxAge and
+// must pass age.
+func olderThan(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	age, err := p.Duration("age", time.Duration(c.MaxAge)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	if age <= 0 {
+		return nil, fmt.Errorf("param age must be greater then 0")
+	}
+
+	return strategy.OlderThan(age), nil
+}
+
+// inStateLongerThan keeps pods that have been in the state of conf.Criteria.Kind for longer than the age param,
+// conf.Criteria.MinAge by default.
+func inStateLongerThan(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	age, err := p.Duration("age", time.Duration(c.MinAge)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return strategy.InStateLongerThan(c.Kind, age), nil
+}
+
+// limit keeps at most the count param pods.
+func limit(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	count, err := p.Int("count", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= 0 {
+		return nil, fmt.Errorf("param count must be greater then 0")
+	}
+
+	return strategy.Limit(count), nil
+}
+
+// random picks pods in a random order with the probability param, 1 by default, weighting by age when the weight param
+// is age. The seed param makes the order reproducible.
+func random(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	probability, err := p.Float("probability", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if probability <= 0 || probability > 1 {
+		return nil, fmt.Errorf("param probability must be greater then 0 and at most 1")
+	}
+
+	seed, err := p.Int("seed", time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	weight := p.String("weight", conf.RandomWeightUniform)
+	if weight != conf.RandomWeightUniform && weight != conf.RandomWeightAge {
+		return nil, fmt.Errorf("param weight '%s' is unknown", weight)
+	}
+
+	return strategy.RandomPick(rand.New(rand.NewSource(seed)), probability, weight == conf.RandomWeightAge), nil
+}
+
+// topology keeps at most the maxPerNode and maxPerDomain params pods per node and per domain of the key param over the
+// window param.
+func topology(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Evaluator, error) {
+	maxPerNode, err := p.Int("maxPerNode", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPerDomain, err := p.Int("maxPerDomain", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := p.Duration("window", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxPerNode <= 0 && maxPerDomain <= 0 {
+		return nil, fmt.Errorf("param maxPerNode or maxPerDomain must be greater then 0")
+	}
+
+	return strategy.TopologySpreadFor(p.String("key", conf.DefaultTopologyKey), maxPerNode, maxPerDomain, window, l), nil
+}
+
+// limitFor truncates the result of the following stages to conf.Criteria.Limit, resolved against the pods it is passed,
+// skipping pods refused by conf.Criteria.Topology.
+func limitFor(c conf.Criteria, l strategy.Lookups, p strategy.StageParams, next strategy.Evaluator) (strategy.Evaluator, error) {
+	return strategy.LimitFor(c, l, next), nil
+}
+
+// coolDown skips the following stages for the duration param, conf.Criteria.CoolDown by default, after they select
+// any pods.
+func coolDown(c conf.Criteria, l strategy.Lookups, p strategy.StageParams, next strategy.Evaluator) (strategy.Evaluator, error) {
+	d, err := p.Duration("duration", time.Duration(c.CoolDown)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return strategy.CoolDownFor(d, l, next), nil
+}
+
+// spread spaces the kicks of the following stages evenly over the maxAge param, conf.Criteria.MaxAge by default.
+func spread(c conf.Criteria, l strategy.Lookups, p strategy.StageParams, next strategy.Evaluator) (strategy.Evaluator, error) {
+	maxAge, err := p.Duration("maxAge", time.Duration(c.MaxAge)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("param maxAge must be greater then 0")
+	}
+
+	return strategy.SpreadFor(maxAge, l, next), nil
+}
+
+// spreadFast spaces the kicks of the following stages over the maxAge param, conf.Criteria.MaxAge by default, passing
+// them only the pods that are due, so a later limit is not used up by pods that are too young.
+func spreadFast(c conf.Criteria, l strategy.Lookups, p strategy.StageParams, next strategy.Evaluator) (strategy.Evaluator, error) {
+	maxAge, err := p.Duration("maxAge", time.Duration(c.MaxAge)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("param maxAge must be greater then 0")
+	}
+
+	return strategy.SpreadFastFor(maxAge, l, strategy.EvaluatorSeive(strategy.OlderThanShare(maxAge), next)), nil
+}
+
+// namePrefix matches pods whose name has the prefix param.
+func namePrefix(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	prefix, err := p.Required("prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	return strategy.NamePrefixFilter(prefix), nil
+}
+
+// phase matches pods in the phase param.
+func phase(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	value, err := p.Required("phase")
+	if err != nil {
+		return nil, err
+	}
+
+	return strategy.StatusFilter(v1.PodPhase(value)), nil
+}
+
+// terminating matches pods marked for deletion.
+func terminating(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	return strategy.TerminatingFilter, nil
+}
+
+// label matches pods with the key param label, equal to the value param if set.
+func label(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	return keyValueFilter(p, func(pod v1.Pod) map[string]string {
+		return pod.Labels
+	})
+}
+
+// annotation matches pods with the key param annotation, equal to the value param if set.
+func annotation(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	return keyValueFilter(p, func(pod v1.Pod) map[string]string {
+		return pod.Annotations
+	})
+}
+
+func keyValueFilter(p strategy.StageParams, get func(v1.Pod) map[string]string) (strategy.Filter, error) {
+	key, err := p.Required("key")
+	if err != nil {
+		return nil, err
+	}
+
+	value, hasValue := p["value"]
+	return func(pod v1.Pod) bool {
+		v, ok := get(pod)[key]
+		return ok && (!hasValue || v == value)
+	}, nil
+}
+
+// triggered matches pods for which conf.Criteria.Triggers fired.
+func triggered(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	if !c.Triggers.Enabled() {
+		return nil, fmt.Errorf("criteria has no triggers")
+	}
+
+	return strategy.TriggeredFilter(c, l.Triggered), nil
+}
+
+// expression matches pods for which the CEL expression in the expr param evaluates to true.
+func expression(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	source, err := p.Required("expr")
+	if err != nil {
+		return nil, err
+	}
+
+	prg, err := expr.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return strategy.ExpressionFilter(prg), nil
+}
+
+// restartsAbove matches pods with a container restarted more than the count param times.
+func restartsAbove(c conf.Criteria, l strategy.Lookups, p strategy.StageParams) (strategy.Filter, error) {
+	count, err := p.Int("count", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(pod v1.Pod) bool {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if int64(cs.RestartCount) > count {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
@@ -0,0 +1,186 @@
+package stages
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/strategy"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fleet returns n running pods of the default namespace, the first being the oldest.
+func fleet(n int) []v1.Pod {
+	start := time.Now().Add(-24 * time.Hour)
+	pods := make([]v1.Pod, 0, n)
+	for i := 0; i < n; i++ {
+		pods = append(pods, v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              fmt.Sprintf("web-%d", i),
+				CreationTimestamp: metav1.NewTime(start.Add(time.Duration(i) * time.Minute)),
+			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		})
+	}
+
+	return pods
+}
+
+func criteria(kind conf.Kind, stages ...conf.Stage) conf.Criteria {
+	return conf.Criteria{
+		Name:      "web",
+		Namespace: "default",
+		Kind:      kind,
+		MaxAge:    3600,
+		Limit:     conf.Limit{Count: 1},
+		Strategy:  conf.StrategyPipeline,
+		Pipeline:  stages,
+	}
+}
+
+func TestCoolDownWrapsFollowingStages(t *testing.T) {
+	eval, err := strategy.Pipeline(criteria(conf.KindRunning,
+		conf.Stage{Stage: "coolDown", Params: map[string]string{"duration": "10m"}},
+		conf.Stage{Stage: "limit", Params: map[string]string{"count": "1"}},
+	), strategy.Lookups{})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %s", err)
+	}
+
+	if got := eval(fleet(3)); len(got) != 1 {
+		t.Fatalf("first evaluation selected %d pods, want 1", len(got))
+	}
+
+	if got := eval(fleet(3)); len(got) != 0 {
+		t.Errorf("evaluation during the cool down selected %d pods, want 0", len(got))
+	}
+}
+
+func TestStagesBeforeCoolDownAreNotCooledDown(t *testing.T) {
+	eval, err := strategy.Pipeline(criteria(conf.KindRunning,
+		conf.Stage{Filter: "namePrefix", Params: map[string]string{"prefix": "web-2"}},
+		conf.Stage{Stage: "coolDown", Params: map[string]string{"duration": "10m"}},
+	), strategy.Lookups{})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %s", err)
+	}
+
+	// the filter leaves nothing for the cool down to select, so it never starts
+	for i := 0; i < 2; i++ {
+		if got := eval(fleet(2)); len(got) != 0 {
+			t.Fatalf("evaluation %d selected %d pods, want 0", i, len(got))
+		}
+	}
+
+	if got := eval(fleet(3)); len(got) != 1 || got[0].Name != "web-2" {
+		t.Errorf("selected %v, want web-2", got)
+	}
+}
+
+func TestLimitForWrapsFollowingStages(t *testing.T) {
+	eval, err := strategy.Pipeline(criteria(conf.KindRunning,
+		conf.Stage{Stage: "limitFor"},
+		conf.Stage{Stage: "sortCreation"},
+	), strategy.Lookups{})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %s", err)
+	}
+
+	pods := fleet(3)
+	pods[0], pods[2] = pods[2], pods[0]
+	if got := eval(pods); len(got) != 1 || got[0].Name != "web-0" {
+		t.Errorf("selected %v, want only the oldest pod web-0", got)
+	}
+}
+
+func TestSpreadFastFiltersDuePodsBeforeLimiting(t *testing.T) {
+	eval, err := strategy.Pipeline(criteria(conf.KindRunning,
+		conf.Stage{Stage: "spreadFast", Params: map[string]string{"maxAge": "1h"}},
+		conf.Stage{Stage: "limit", Params: map[string]string{"count": "1"}},
+	), strategy.Lookups{})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %s", err)
+	}
+
+	// web-0 is younger than the spread interval, so it must not use up the limit ahead of the due web-1
+	pods := fleet(2)
+	pods[0].CreationTimestamp = metav1.Now()
+	if got := eval(pods); len(got) != 1 || got[0].Name != "web-1" {
+		t.Errorf("selected %v, want the due pod web-1", got)
+	}
+}
+
+func TestParamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  conf.Kind
+		stage conf.Stage
+		err   string
+	}{
+		{
+			name:  "limit count",
+			stage: conf.Stage{Stage: "limit", Params: map[string]string{"count": "0"}},
+			err:   "pipeline stage 1 limit: param count must be greater then 0",
+		},
+		{
+			name:  "limit not an integer",
+			stage: conf.Stage{Stage: "limit", Params: map[string]string{"count": "one"}},
+			err:   "pipeline stage 1 limit: param count 'one' is not an integer",
+		},
+		{
+			name:  "coolDown duration",
+			stage: conf.Stage{Stage: "coolDown", Params: map[string]string{"duration": "soon"}},
+			err:   "pipeline stage 1 coolDown: param duration 'soon' is not a duration",
+		},
+		{
+			name:  "random probability",
+			stage: conf.Stage{Stage: "random", Params: map[string]string{"probability": "2"}},
+			err:   "pipeline stage 1 random: param probability must be greater then 0 and at most 1",
+		},
+		{
+			name:  "topology without a max",
+			stage: conf.Stage{Stage: "topology"},
+			err:   "pipeline stage 1 topology: param maxPerNode or maxPerDomain must be greater then 0",
+		},
+		{
+			name:  "olderThan of a cleanup kind",
+			kind:  conf.KindFailed,
+			stage: conf.Stage{Stage: "olderThan"},
+			err:   "pipeline stage 1 olderThan: param age must be greater then 0",
+		},
+		{
+			name:  "spread of a cleanup kind",
+			kind:  conf.KindSucceeded,
+			stage: conf.Stage{Stage: "spread"},
+			err:   "pipeline stage 1 spread: param maxAge must be greater then 0",
+		},
+		{
+			name:  "label filter key",
+			stage: conf.Stage{Filter: "label", Not: true},
+			err:   "pipeline filter 1 label: param key is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind := tt.kind
+			if kind == "" {
+				kind = conf.KindRunning
+			}
+
+			c := criteria(kind, tt.stage)
+			if kind.IsCleanup() {
+				c.MaxAge = 0
+			}
+
+			_, err := strategy.Pipeline(c, strategy.Lookups{})
+			if err == nil || !strings.HasSuffix(err.Error(), tt.err) {
+				t.Errorf("Pipeline() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
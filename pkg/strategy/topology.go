@@ -0,0 +1,120 @@
+package strategy
+
+import (
+	"log"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+// NodeLabeler returns the labels of the named node, or nil if the node is not known
+type NodeLabeler func(node string) map[string]string
+
+// topologyKick records a pod selected by TopologySpread
+type topologyKick struct {
+	at     time.Time
+	node   string
+	domain string
+}
+
+// TopologySpread returns an Evaluator that keeps pods in order while limiting them to maxPerNode pods per node and
+// maxPerDomain pods per topology domain, where the domain of a pod is the value of the key label of its node. Limits
+// of 0 are ignored. Pods selected within the trailing window count towards the limits; a window of 0 applies the limits
+// per evaluation. Pods without a node, or on a node without the key label, only count towards the node limit.
+func TopologySpread(key string, maxPerNode, maxPerDomain int64, window time.Duration, nodes NodeLabeler) Evaluator {
+	return TopologySpreadFor(key, maxPerNode, maxPerDomain, window, Lookups{Nodes: nodes})
+}
+
+// TopologySpreadFor is TopologySpread resolving nodes with Lookups.Nodes, where only pods that were kicked count
+// towards the limits of later evaluations, see Lookups.OnKick.
+func TopologySpreadFor(key string, maxPerNode, maxPerDomain int64, window time.Duration, l Lookups) Evaluator {
+	t := newTopologySpread(key, maxPerNode, maxPerDomain, window, l)
+	return func(pods []v1.Pod) []v1.Pod {
+		log.Printf("TopologySpread called with %d pods", len(pods))
+		out := t.pick(pods, -1)
+		log.Printf("TopologySpread exiting with %d pods", len(out))
+		return out
+	}
+}
+
+// topologySpread holds the limits of TopologySpread and the pods it selected within its window.
+type topologySpread struct {
+	key          string
+	maxPerNode   int64
+	maxPerDomain int64
+	window       time.Duration
+	nodes        NodeLabeler
+	history      []topologyKick
+	kicks        *kickCommit
+}
+
+func newTopologySpread(key string, maxPerNode, maxPerDomain int64, window time.Duration, l Lookups) *topologySpread {
+	t := &topologySpread{
+		key:          key,
+		maxPerNode:   maxPerNode,
+		maxPerDomain: maxPerDomain,
+		window:       window,
+		nodes:        l.Nodes,
+	}
+	t.kicks = newKickCommit(l, t.kicked)
+	return t
+}
+
+// domain returns the topology domain of the node of the passed pod, empty if it is not known.
+func (t *topologySpread) domain(pod v1.Pod) string {
+	if pod.Spec.NodeName == "" || t.nodes == nil {
+		return ""
+	}
+
+	return t.nodes(pod.Spec.NodeName)[t.key]
+}
+
+// kicked adds a kicked pod to the history of the window.
+func (t *topologySpread) kicked(pod v1.Pod, at time.Time) {
+	if t.window > 0 {
+		t.history = append(t.history, topologyKick{at: at, node: pod.Spec.NodeName, domain: t.domain(pod)})
+	}
+}
+
+// pick keeps pods in order while they are within the limits, stopping once max pods are kept. A negative max keeps
+// every pod within the limits. Only kept pods count towards the limits of later evaluations, once they are kicked.
+func (t *topologySpread) pick(pods []v1.Pod, max int64) []v1.Pod {
+	now := timeNow()
+
+	perNode := map[string]int64{}
+	perDomain := map[string]int64{}
+	kept := t.history[:0]
+	for _, k := range t.history {
+		if now.Sub(k.at) < t.window {
+			kept = append(kept, k)
+			perNode[k.node]++
+			perDomain[k.domain]++
+		}
+	}
+	t.history = kept
+
+	out := make([]v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if max >= 0 && int64(len(out)) >= max {
+			break
+		}
+
+		node := pod.Spec.NodeName
+		domain := t.domain(pod)
+
+		if t.maxPerNode > 0 && node != "" && perNode[node] >= t.maxPerNode {
+			continue
+		}
+
+		if t.maxPerDomain > 0 && domain != "" && perDomain[domain] >= t.maxPerDomain {
+			continue
+		}
+
+		perNode[node]++
+		perDomain[domain]++
+		out = append(out, pod)
+	}
+
+	t.kicks.record(out, now)
+	return out[:len(out):len(out)]
+}
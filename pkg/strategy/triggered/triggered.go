@@ -0,0 +1,42 @@
+package triggered
+
+import (
+	"log"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"github.com/curlymon/kicker/pkg/strategy"
+)
+
+func init() {
+	if err := strategy.RegisterLookupEvaluatorConstructor(conf.StrategyTriggered, Triggered); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Triggered defines the triggered strategy evaluation.
+// Only pods for which conf.Criteria.Triggers fired are kicked; the others still count towards a percentage limit.
+// It first filters the passed list of pods to the setting defined in the passed conf.Criteria.
+// Then it sorts pods oldest to newest by v1.Pod.CreationTimestamp.Time and removes pods younger than
+// conf.Criteria.MinAge.
+// Last it kicks pods in order until conf.Criteria.Limit, resolved against the matched pods, is reached.
+// If a pod is kicked, a cooldown for re-evaluation is triggered with a length of conf.Criteria.CoolDown.
+func Triggered(c conf.Criteria, l strategy.Lookups) strategy.Evaluator {
+	// build a logic core that assumes filtered pods
+	core := strategy.EvaluatorSeive(
+		strategy.SortCreationTimestampAsc,
+		strategy.OlderThan(time.Duration(c.MinAge)*time.Second),
+	)
+
+	// build a filter top remove all non matching and unhealthy pods
+	filter := strategy.CriteriaFilter(c)
+
+	// setup prefilter
+	prefilter := strategy.EvaluatorSeive(
+		strategy.ApplyFilter(filter),
+		strategy.LimitFor(c, l, core),
+	)
+
+	// wrap prefilter strategy with cooldown
+	return strategy.CoolDownFor(time.Duration(c.CoolDown)*time.Second, l, prefilter)
+}
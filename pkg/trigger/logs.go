@@ -0,0 +1,152 @@
+package trigger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LogReader reads the logs of a pod's container.
+type LogReader interface {
+	// Logs returns the logs of the named pod selected by opts
+	Logs(namespace, pod string, opts *v1.PodLogOptions) ([]byte, error)
+}
+
+// ClientLogReader implements LogReader with the pods/log subresource.
+type ClientLogReader struct {
+	clientset kubernetes.Interface
+}
+
+// NewClientLogReader builds a ClientLogReader using the passed clientset.
+func NewClientLogReader(clientset kubernetes.Interface) *ClientLogReader {
+	return &ClientLogReader{clientset: clientset}
+}
+
+// Logs implements LogReader.
+func (c *ClientLogReader) Logs(namespace, pod string, opts *v1.PodLogOptions) ([]byte, error) {
+	return c.clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).DoRaw()
+}
+
+// logResult is the cached outcome of reading a pod's logs
+type logResult struct {
+	at time.Time
+
+	// reason is why the trigger fired, empty if it did not
+	reason string
+}
+
+// logTrigger implements conf.LogTrigger
+type logTrigger struct {
+	c       conf.LogTrigger
+	pattern *regexp.Regexp
+	reader  LogReader
+
+	mu    sync.Mutex
+	cache map[string]logResult
+}
+
+func newLogTrigger(c conf.LogTrigger, reader LogReader) *logTrigger {
+	return &logTrigger{
+		c: c,
+		// the pattern has already been validated by conf
+		pattern: regexp.MustCompile(c.Pattern),
+		reader:  reader,
+		cache:   map[string]logResult{},
+	}
+}
+
+// Fired implements Trigger. Logs are read by at most conf.LogTrigger.Concurrency workers, and a pod whose logs were read
+// within conf.LogTrigger.CacheFor reuses that result. Pods whose logs cannot be read do not fire.
+func (l *logTrigger) Fired(pods []v1.Pod, now time.Time) map[string]string {
+	cacheFor := time.Duration(l.c.CacheFor) * time.Second
+	seen := make(map[string]bool, len(pods))
+	var stale []v1.Pod
+	l.mu.Lock()
+	for _, pod := range pods {
+		key := Key(pod)
+		seen[key] = true
+		if r, ok := l.cache[key]; !ok || now.Sub(r.at) >= cacheFor {
+			stale = append(stale, pod)
+		}
+	}
+
+	for key := range l.cache {
+		if !seen[key] {
+			delete(l.cache, key)
+		}
+	}
+	l.mu.Unlock()
+
+	work := make(chan v1.Pod)
+	var wg sync.WaitGroup
+	for i := int64(0); i < l.c.Concurrency && i < int64(len(stale)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pod := range work {
+				reason := l.read(pod)
+				l.mu.Lock()
+				l.cache[Key(pod)] = logResult{at: now, reason: reason}
+				l.mu.Unlock()
+			}
+		}()
+	}
+
+	for _, pod := range stale {
+		work <- pod
+	}
+	close(work)
+	wg.Wait()
+
+	out := map[string]string{}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range seen {
+		if reason := l.cache[key].reason; reason != "" {
+			out[key] = reason
+		}
+	}
+
+	return out
+}
+
+// read returns why the trigger fired if the pod's logs contain at least conf.LogTrigger.Matches lines matching the
+// pattern, or an empty string if they do not.
+func (l *logTrigger) read(pod v1.Pod) string {
+	opts := &v1.PodLogOptions{
+		Container: l.c.Container,
+		TailLines: &l.c.TailLines,
+	}
+
+	if l.c.Since > 0 {
+		opts.SinceSeconds = &l.c.Since
+	}
+
+	raw, err := l.reader.Logs(pod.Namespace, pod.Name, opts)
+	if err != nil {
+		log.Printf("error reading logs of %s container %s: %s", Key(pod), l.c.Container, err)
+		return ""
+	}
+
+	var matches int64
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		if l.pattern.Match(scanner.Bytes()) {
+			matches++
+			if matches >= l.c.Matches {
+				log.Printf("log trigger fired for pod %s after %d matches of '%s'", Key(pod), matches, l.c.Pattern)
+				return fmt.Sprintf("%d log lines match '%s'", matches, l.c.Pattern)
+			}
+		}
+	}
+
+	return ""
+}
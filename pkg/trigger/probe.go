@@ -0,0 +1,180 @@
+package trigger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// maxProbeBody is the number of bytes of a probe response read when matching conf.ProbeTrigger.Body
+const maxProbeBody = 64 * 1024
+
+// probeTrigger implements conf.ProbeTrigger
+type probeTrigger struct {
+	c      conf.ProbeTrigger
+	body   *regexp.Regexp
+	client *http.Client
+
+	mu       sync.Mutex
+	failures map[string]int64
+
+	// errs holds the error of the last failed probe of each pod with failures
+	errs map[string]error
+}
+
+func newProbeTrigger(c conf.ProbeTrigger) *probeTrigger {
+	p := &probeTrigger{
+		c: c,
+		client: &http.Client{
+			Timeout: time.Duration(c.Timeout) * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				DisableKeepAlives: true,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		failures: map[string]int64{},
+		errs:     map[string]error{},
+	}
+
+	if c.Body != "" {
+		// the pattern has already been validated by conf
+		p.body = regexp.MustCompile(c.Body)
+	}
+
+	return p
+}
+
+// Fired implements Trigger. Probes are run by at most conf.ProbeTrigger.Concurrency workers. Pods without an IP are not
+// probed and keep their previous count of consecutive failures.
+func (p *probeTrigger) Fired(pods []v1.Pod, now time.Time) map[string]string {
+	seen := make(map[string]bool, len(pods))
+	work := make(chan v1.Pod)
+	var wg sync.WaitGroup
+	for i := int64(0); i < p.c.Concurrency && i < int64(len(pods)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pod := range work {
+				p.observe(pod, p.probe(pod))
+			}
+		}()
+	}
+
+	for _, pod := range pods {
+		seen[Key(pod)] = true
+		if pod.Status.PodIP != "" {
+			work <- pod
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	out := map[string]string{}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, failures := range p.failures {
+		if !seen[key] {
+			delete(p.failures, key)
+			delete(p.errs, key)
+			continue
+		}
+
+		if failures >= p.c.Failures {
+			out[key] = fmt.Sprintf("probe failed %d consecutive times: %s", failures, p.errs[key])
+		}
+	}
+
+	return out
+}
+
+// observe records the result of probing the pod, resetting its consecutive failures on success.
+func (p *probeTrigger) observe(pod v1.Pod, err error) {
+	key := Key(pod)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		delete(p.failures, key)
+		delete(p.errs, key)
+		return
+	}
+
+	p.failures[key]++
+	p.errs[key] = err
+	failures := p.failures[key]
+	if failures >= p.c.Failures {
+		log.Printf("probe trigger fired for pod %s after %d consecutive failures: %s", key, failures, err)
+	} else {
+		log.Printf("probe of pod %s failed %d of %d times: %s", key, failures, p.c.Failures, err)
+	}
+}
+
+// probe runs a single probe against the pod, returning why it failed or nil if it succeeded.
+func (p *probeTrigger) probe(pod v1.Pod) error {
+	scheme := "http"
+	if p.c.HTTPS {
+		scheme = "https"
+	}
+
+	host := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(p.c.Port)))
+	req, err := http.NewRequest(http.MethodGet, scheme+"://"+host+p.c.Path, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range p.c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !p.expected(resp.StatusCode) {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if p.body == nil {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return fmt.Errorf("error reading body: %s", err)
+	}
+
+	if !p.body.Match(body) {
+		return fmt.Errorf("body does not match '%s'", p.c.Body)
+	}
+
+	return nil
+}
+
+func (p *probeTrigger) expected(status int) bool {
+	if len(p.c.Status) <= 0 {
+		return status >= 200 && status < 400
+	}
+
+	for _, s := range p.c.Status {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
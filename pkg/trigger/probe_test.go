@@ -0,0 +1,48 @@
+package trigger
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+func TestProbeTriggerReasons(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("error parsing the server address: %s", err)
+	}
+
+	p, _ := strconv.Atoi(port)
+	probe := newProbeTrigger(conf.ProbeTrigger{Port: int32(p), Path: "/healthz", Timeout: 5, Failures: 2, Concurrency: 1})
+	pod := limitedPod("default", "web")
+	pod.Status.PodIP = host
+	pods := []v1.Pod{pod}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if fired := probe.Fired(pods, now); len(fired) != 0 {
+		t.Errorf("expected the first failure not to fire, got %v", fired)
+	}
+
+	fired := probe.Fired(pods, now.Add(time.Minute))
+	if reason := fired["default/web"]; !strings.Contains(reason, "2 consecutive times") || !strings.Contains(reason, "500") {
+		t.Errorf("expected the reason to give the failures and the status, got '%s'", reason)
+	}
+
+	status = http.StatusOK
+	if fired := probe.Fired(pods, now.Add(2*time.Minute)); len(fired) != 0 {
+		t.Errorf("expected a successful probe to reset the trigger, got %v", fired)
+	}
+}
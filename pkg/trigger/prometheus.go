@@ -0,0 +1,154 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// Sample is a single series of an instant vector returned by a PromQL query.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// PromQuerier runs instant PromQL queries.
+type PromQuerier interface {
+	// Query evaluates query at the passed time and returns the resulting instant vector
+	Query(query string, at time.Time) ([]Sample, error)
+}
+
+// HTTPQuerier implements PromQuerier against the Prometheus HTTP API.
+type HTTPQuerier struct {
+	c      conf.Prometheus
+	client *http.Client
+}
+
+// NewHTTPQuerier builds an HTTPQuerier for the passed conf.Prometheus.
+func NewHTTPQuerier(c conf.Prometheus) *HTTPQuerier {
+	return &HTTPQuerier{
+		c:      c,
+		client: &http.Client{Timeout: time.Duration(c.Timeout) * time.Second},
+	}
+}
+
+// queryResponse is the body returned by /api/v1/query
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Query implements PromQuerier.
+func (h *HTTPQuerier) Query(query string, at time.Time) ([]Sample, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time", strconv.FormatFloat(float64(at.UnixNano())/1e9, 'f', 3, 64))
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(h.c.URL, "/")+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range h.c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding response with status %s: %s", resp.Status, err)
+	}
+
+	if body.Status != "success" {
+		return nil, fmt.Errorf("query failed with status %s: %s", resp.Status, body.Error)
+	}
+
+	if body.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("query returned a %s, expected a vector", body.Data.ResultType)
+	}
+
+	out := make([]Sample, 0, len(body.Data.Result))
+	for _, r := range body.Data.Result {
+		s, ok := r.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("query returned a malformed value %v", r.Value)
+		}
+
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query returned a malformed value '%s': %s", s, err)
+		}
+
+		out = append(out, Sample{Labels: r.Metric, Value: value})
+	}
+
+	return out, nil
+}
+
+// prometheusTrigger implements conf.PrometheusTrigger
+type prometheusTrigger struct {
+	c       conf.PrometheusTrigger
+	querier PromQuerier
+	held    sustained
+}
+
+func newPrometheusTrigger(c conf.PrometheusTrigger, querier PromQuerier) *prometheusTrigger {
+	return &prometheusTrigger{
+		c:       c,
+		querier: querier,
+		held:    newSustained(time.Duration(c.For) * time.Second),
+	}
+}
+
+// Fired implements Trigger. If the query fails every pod keeps its previous state.
+func (p *prometheusTrigger) Fired(pods []v1.Pod, now time.Time) map[string]string {
+	out := map[string]string{}
+	if len(pods) <= 0 {
+		return out
+	}
+
+	samples, err := p.querier.Query(p.c.Query, now)
+	if err != nil {
+		log.Printf("error running prometheus trigger query, trigger will not fire: %s", err)
+		return out
+	}
+
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		values[s.Labels[p.c.NamespaceLabel]+"/"+s.Labels[p.c.PodLabel]] = s.Value
+	}
+
+	seen := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		key := Key(pod)
+		seen[key] = true
+		value, ok := values[key]
+		if p.held.observe(key, ok && value > p.c.Threshold, now) {
+			log.Printf("prometheus trigger fired for pod %s with value %g", key, value)
+			out[key] = fmt.Sprintf("prometheus query value %g is above %g", value, p.c.Threshold)
+		}
+	}
+
+	p.held.prune(seen)
+	return out
+}
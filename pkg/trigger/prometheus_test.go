@@ -0,0 +1,237 @@
+package trigger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// prometheusServer serves the status code and body returned by respond from /api/v1/query.
+func prometheusServer(t *testing.T, respond func(r *http.Request) (int, string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		code, body := respond(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func vector(samples ...string) string {
+	return `{"status":"success","data":{"resultType":"vector","result":[` + strings.Join(samples, ",") + `]}}`
+}
+
+func TestHTTPQuerier(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     int
+		body     string
+		expected []Sample
+		err      bool
+	}{
+		{
+			name: "vector",
+			code: http.StatusOK,
+			body: vector(
+				`{"metric":{"namespace":"default","pod":"web-1"},"value":[1577836800,"0.5"]}`,
+				`{"metric":{"namespace":"default","pod":"web-2"},"value":[1577836800,"12"]}`,
+			),
+			expected: []Sample{
+				{Labels: map[string]string{"namespace": "default", "pod": "web-1"}, Value: 0.5},
+				{Labels: map[string]string{"namespace": "default", "pod": "web-2"}, Value: 12},
+			},
+		},
+		{
+			name:     "empty vector",
+			code:     http.StatusOK,
+			body:     vector(),
+			expected: []Sample{},
+		},
+		{
+			name: "error status",
+			code: http.StatusBadRequest,
+			body: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			err:  true,
+		},
+		{
+			name: "matrix",
+			code: http.StatusOK,
+			body: `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			err:  true,
+		},
+		{
+			name: "malformed value",
+			code: http.StatusOK,
+			body: vector(`{"metric":{"pod":"web-1"},"value":[1577836800,"high"]}`),
+			err:  true,
+		},
+		{
+			name: "not json",
+			code: http.StatusBadGateway,
+			body: "bad gateway",
+			err:  true,
+		},
+	}
+
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := prometheusServer(t, func(r *http.Request) (int, string) {
+				if q := r.URL.Query().Get("query"); q != "up" {
+					t.Errorf("expected query up, got %s", q)
+				}
+
+				if ts := r.URL.Query().Get("time"); ts != "1577836800.000" {
+					t.Errorf("expected time 1577836800.000, got %s", ts)
+				}
+
+				if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+					t.Errorf("expected the configured Authorization header, got '%s'", auth)
+				}
+
+				return tc.code, tc.body
+			})
+			defer server.Close()
+
+			q := NewHTTPQuerier(conf.Prometheus{
+				URL:     server.URL + "/",
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Timeout: 5,
+			})
+			samples, err := q.Query("up", at)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", samples)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(samples, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, samples)
+			}
+		})
+	}
+}
+
+func TestPrometheusTrigger(t *testing.T) {
+	// failing stands for an unreachable Prometheus, an empty value for a pod missing from the result
+	type observation struct {
+		after   time.Duration
+		value   string
+		failing bool
+		fired   bool
+	}
+
+	cases := []struct {
+		name         string
+		sustain      int64
+		observations []observation
+	}{
+		{
+			name: "fires above the threshold",
+			observations: []observation{
+				{value: "5"},
+				{after: time.Minute, value: "5.5", fired: true},
+			},
+		},
+		{
+			name: "missing series does not fire",
+			observations: []observation{
+				{},
+			},
+		},
+		{
+			name:    "for must be sustained",
+			sustain: 120,
+			observations: []observation{
+				{value: "10"},
+				{after: time.Minute, value: "10"},
+				{after: time.Minute, value: "10", fired: true},
+			},
+		},
+		{
+			name:    "missing series resets for",
+			sustain: 120,
+			observations: []observation{
+				{value: "10"},
+				{after: time.Minute},
+				{after: time.Minute, value: "10"},
+				{after: time.Minute, value: "10"},
+				{after: time.Minute, value: "10", fired: true},
+			},
+		},
+		{
+			name:    "failing query neither fires nor resets for",
+			sustain: 120,
+			observations: []observation{
+				{value: "10"},
+				{after: time.Minute, failing: true},
+				{after: time.Minute, failing: true},
+				{after: time.Second, value: "10", fired: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var current observation
+			server := prometheusServer(t, func(r *http.Request) (int, string) {
+				if current.failing {
+					return http.StatusServiceUnavailable, `{"status":"error","error":"unavailable"}`
+				}
+
+				if current.value == "" {
+					return http.StatusOK, vector()
+				}
+
+				return http.StatusOK, vector(`{"metric":{"ns":"default","name":"web"},"value":[1577836800,"` + current.value + `"]}`)
+			})
+			defer server.Close()
+
+			p := newPrometheusTrigger(conf.PrometheusTrigger{
+				Query:          "rate(errors[5m])",
+				Threshold:      5,
+				For:            tc.sustain,
+				PodLabel:       "name",
+				NamespaceLabel: "ns",
+			}, NewHTTPQuerier(conf.Prometheus{URL: server.URL, Timeout: 5}))
+			pods := []v1.Pod{limitedPod("default", "web"), limitedPod("other", "web")}
+
+			now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, o := range tc.observations {
+				now = now.Add(o.after)
+				current = o
+
+				fired := p.Fired(pods, now)
+				reason, ok := fired["default/web"]
+				if ok != o.fired {
+					t.Errorf("observation %d: expected fired %v, got %v", i, o.fired, ok)
+				}
+
+				if ok && reason == "" {
+					t.Errorf("observation %d: fired without a reason", i)
+				}
+
+				if _, ok := fired["other/web"]; ok {
+					t.Errorf("observation %d: pod of another namespace fired", i)
+				}
+			}
+		})
+	}
+}
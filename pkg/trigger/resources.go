@@ -0,0 +1,171 @@
+package trigger
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// MetricsCache reads PodMetrics from the metrics.k8s.io API one namespace at a time, so that only namespaces with
+// resource triggers are fetched, and caches them until Reset. It is safe for concurrent use.
+type MetricsCache struct {
+	client metricsclient.Interface
+
+	mu          sync.Mutex
+	byNamespace map[string]map[string]*metricsv1beta1.PodMetrics
+}
+
+// NewMetricsCache builds a MetricsCache reading from the passed client.
+func NewMetricsCache(client metricsclient.Interface) *MetricsCache {
+	return &MetricsCache{
+		client:      client,
+		byNamespace: map[string]map[string]*metricsv1beta1.PodMetrics{},
+	}
+}
+
+// Reset drops all cached metrics. It should be called once per evaluation.
+func (m *MetricsCache) Reset() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byNamespace = map[string]map[string]*metricsv1beta1.PodMetrics{}
+}
+
+// Pod returns the metrics of the named pod, or nil if there are none. When the metrics API is unavailable, e.g. because
+// metrics-server is not installed, the error is logged once per namespace per evaluation and nil is returned.
+func (m *MetricsCache) Pod(namespace, name string) *metricsv1beta1.PodMetrics {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pods, ok := m.byNamespace[namespace]
+	if !ok {
+		pods = map[string]*metricsv1beta1.PodMetrics{}
+		list, err := m.client.MetricsV1beta1().PodMetricses(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			log.Printf("error reading pod metrics in namespace %s, resource triggers will not fire: %s", namespace, err)
+		} else {
+			for i := range list.Items {
+				pods[list.Items[i].Name] = &list.Items[i]
+			}
+		}
+
+		m.byNamespace[namespace] = pods
+	}
+
+	return pods[name]
+}
+
+// resourceTrigger implements conf.ResourceTrigger
+type resourceTrigger struct {
+	c       conf.ResourceTrigger
+	memory  *conf.Threshold
+	cpu     *conf.Threshold
+	metrics *MetricsCache
+	held    sustained
+}
+
+func newResourceTrigger(c conf.ResourceTrigger, metrics *MetricsCache) *resourceTrigger {
+	// thresholds have already been validated by conf
+	memory, _ := conf.ParseThreshold(c.Memory)
+	cpu, _ := conf.ParseThreshold(c.CPU)
+	return &resourceTrigger{
+		c:       c,
+		memory:  memory,
+		cpu:     cpu,
+		metrics: metrics,
+		held:    newSustained(time.Duration(c.For) * time.Second),
+	}
+}
+
+// Fired implements Trigger. Pods without metrics keep their previous state so that a metrics outage neither fires nor
+// resets the trigger.
+func (r *resourceTrigger) Fired(pods []v1.Pod, now time.Time) map[string]string {
+	out := map[string]string{}
+	seen := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		key := Key(pod)
+		seen[key] = true
+		pm := r.metrics.Pod(pod.Namespace, pod.Name)
+		if pm == nil {
+			continue
+		}
+
+		reason := r.exceeded(pod, pm)
+		if r.held.observe(key, reason != "", now) {
+			log.Printf("resource trigger fired for pod %s: %s", key, reason)
+			out[key] = reason
+		}
+	}
+
+	r.held.prune(seen)
+	return out
+}
+
+// exceeded returns which watched container of the pod is at or above a threshold, or an empty string if none is.
+func (r *resourceTrigger) exceeded(pod v1.Pod, pm *metricsv1beta1.PodMetrics) string {
+	for _, cm := range pm.Containers {
+		if r.c.Container != "" && cm.Name != r.c.Container {
+			continue
+		}
+
+		limits := containerLimits(pod, cm.Name)
+		if over(cm.Usage, limits, v1.ResourceMemory, r.memory) {
+			used := cm.Usage[v1.ResourceMemory]
+			return fmt.Sprintf("container %s memory usage %s is at or above %s", cm.Name, used.String(), r.c.Memory)
+		}
+
+		if over(cm.Usage, limits, v1.ResourceCPU, r.cpu) {
+			used := cm.Usage[v1.ResourceCPU]
+			return fmt.Sprintf("container %s cpu usage %s is at or above %s", cm.Name, used.String(), r.c.CPU)
+		}
+	}
+
+	return ""
+}
+
+func containerLimits(pod v1.Pod, name string) v1.ResourceList {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == name {
+			return c.Resources.Limits
+		}
+	}
+
+	return nil
+}
+
+// over compares the usage of a resource against a threshold. Percentage thresholds never fire for containers without a
+// limit on the resource.
+func over(usage, limits v1.ResourceList, name v1.ResourceName, t *conf.Threshold) bool {
+	if t == nil {
+		return false
+	}
+
+	used, ok := usage[name]
+	if !ok {
+		return false
+	}
+
+	if t.Quantity != nil {
+		return used.Cmp(*t.Quantity) >= 0
+	}
+
+	limit, ok := limits[name]
+	if !ok || limit.IsZero() {
+		return false
+	}
+
+	return float64(used.MilliValue()) >= float64(limit.MilliValue())*t.Percent/100
+}
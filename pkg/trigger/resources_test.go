@@ -0,0 +1,218 @@
+package trigger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+// usage is the memory and CPU used by a container at one observation. An empty usage means metrics are unavailable.
+type usage struct {
+	memory string
+	cpu    string
+}
+
+type observation struct {
+	after time.Duration
+	usage map[string]usage
+	fired bool
+}
+
+// fakeMetrics serves the usage returned by current as the PodMetrics of the pod named name in namespace ns. When current
+// returns nil the metrics API fails as if metrics-server was not installed.
+func fakeMetrics(ns, name string, current func() map[string]usage) *MetricsCache {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		containers := current()
+		if containers == nil {
+			return true, nil, errors.New("the server could not find the requested resource")
+		}
+
+		list := &metricsv1beta1.PodMetricsList{}
+		if action.GetNamespace() != ns {
+			return true, list, nil
+		}
+
+		pm := metricsv1beta1.PodMetrics{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name}}
+		for container, u := range containers {
+			cm := metricsv1beta1.ContainerMetrics{Name: container, Usage: v1.ResourceList{}}
+			if u.memory != "" {
+				cm.Usage[v1.ResourceMemory] = resource.MustParse(u.memory)
+			}
+
+			if u.cpu != "" {
+				cm.Usage[v1.ResourceCPU] = resource.MustParse(u.cpu)
+			}
+
+			pm.Containers = append(pm.Containers, cm)
+		}
+
+		list.Items = append(list.Items, pm)
+		return true, list, nil
+	})
+
+	return NewMetricsCache(client)
+}
+
+// limitedPod returns a pod whose app container has memory and cpu limits, the fixture every test of this package builds
+// its pods from.
+func limitedPod(ns, name string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "app",
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse("1Gi"),
+							v1.ResourceCPU:    resource.MustParse("1"),
+						},
+					},
+				},
+				{Name: "sidecar"},
+			},
+		},
+	}
+}
+
+func TestResourceTrigger(t *testing.T) {
+	cases := []struct {
+		name         string
+		c            conf.ResourceTrigger
+		observations []observation
+	}{
+		{
+			name: "absolute memory threshold",
+			c:    conf.ResourceTrigger{Memory: "512Mi"},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "256Mi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "512Mi"}}, fired: true},
+			},
+		},
+		{
+			name: "absolute cpu threshold",
+			c:    conf.ResourceTrigger{CPU: "900m"},
+			observations: []observation{
+				{usage: map[string]usage{"app": {cpu: "800m"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {cpu: "950m"}}, fired: true},
+			},
+		},
+		{
+			name: "percentage of the limit",
+			c:    conf.ResourceTrigger{Memory: "90%"},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "900Mi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "950Mi"}}, fired: true},
+			},
+		},
+		{
+			name: "percentage without a limit never fires",
+			c:    conf.ResourceTrigger{Memory: "90%"},
+			observations: []observation{
+				{usage: map[string]usage{"sidecar": {memory: "10Gi"}}},
+			},
+		},
+		{
+			name: "only the watched container",
+			c:    conf.ResourceTrigger{Container: "sidecar", Memory: "512Mi"},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "1Gi"}, "sidecar": {memory: "128Mi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "128Mi"}, "sidecar": {memory: "1Gi"}}, fired: true},
+			},
+		},
+		{
+			name: "any threshold fires",
+			c:    conf.ResourceTrigger{Memory: "512Mi", CPU: "900m"},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "128Mi", cpu: "950m"}}, fired: true},
+			},
+		},
+		{
+			name: "for must be sustained",
+			c:    conf.ResourceTrigger{Memory: "512Mi", For: 120},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "1Gi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "1Gi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "1Gi"}}, fired: true},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "1Gi"}}, fired: true},
+			},
+		},
+		{
+			name: "dropping below resets for",
+			c:    conf.ResourceTrigger{Memory: "512Mi", For: 120},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "1Gi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "256Mi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "1Gi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "1Gi"}}},
+				{after: time.Minute, usage: map[string]usage{"app": {memory: "1Gi"}}, fired: true},
+			},
+		},
+		{
+			name: "metrics outage neither fires nor resets for",
+			c:    conf.ResourceTrigger{Memory: "512Mi", For: 120},
+			observations: []observation{
+				{usage: map[string]usage{"app": {memory: "1Gi"}}},
+				{after: time.Minute},
+				{after: time.Minute},
+				{after: time.Second, usage: map[string]usage{"app": {memory: "1Gi"}}, fired: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var current map[string]usage
+			metrics := fakeMetrics("default", "web", func() map[string]usage { return current })
+			r := newResourceTrigger(tc.c, metrics)
+			pods := []v1.Pod{limitedPod("default", "web"), limitedPod("other", "web")}
+
+			now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, o := range tc.observations {
+				now = now.Add(o.after)
+				current = o.usage
+				metrics.Reset()
+
+				fired := r.Fired(pods, now)
+				reason, ok := fired["default/web"]
+				if ok != o.fired {
+					t.Errorf("observation %d: expected fired %v, got %v", i, o.fired, ok)
+				}
+
+				if ok && reason == "" {
+					t.Errorf("observation %d: fired without a reason", i)
+				}
+
+				if _, ok := fired["other/web"]; ok {
+					t.Errorf("observation %d: pod without metrics fired", i)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceTriggerForgetsDeletedPods(t *testing.T) {
+	current := map[string]usage{"app": {memory: "1Gi"}}
+	metrics := fakeMetrics("default", "web", func() map[string]usage { return current })
+	r := newResourceTrigger(conf.ResourceTrigger{Memory: "512Mi", For: 60}, metrics)
+	pods := []v1.Pod{limitedPod("default", "web")}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.Fired(pods, now)
+	r.Fired(nil, now.Add(30*time.Second))
+
+	metrics.Reset()
+	if _, ok := r.Fired(pods, now.Add(time.Minute))["default/web"]; ok {
+		t.Errorf("expected a recreated pod to start over, but it fired")
+	}
+}
@@ -0,0 +1,120 @@
+package trigger
+
+import (
+	"strings"
+	"time"
+
+	"github.com/curlymon/kicker/pkg/conf"
+	"k8s.io/api/core/v1"
+)
+
+// Trigger decides which pods of a criteria show a symptom that makes them candidates for kicking. Triggers are stateful
+// across evaluations so that symptoms can be required to persist.
+type Trigger interface {
+	// Fired returns why the trigger has fired at now, keyed by the Keys of the passed pods it fired for
+	Fired(pods []v1.Pod, now time.Time) map[string]string
+}
+
+// Sources are the shared data sources triggers read from. A source is only required by the triggers that use it.
+type Sources struct {
+	// Metrics reads pod metrics from the metrics.k8s.io API
+	Metrics *MetricsCache
+
+	// Prometheus runs PromQL queries
+	Prometheus PromQuerier
+
+	// Logs reads container logs
+	Logs LogReader
+}
+
+// Key returns the key identifying the passed pod in the maps returned by Trigger.Fired.
+func Key(pod v1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// New builds a Trigger from the passed conf.Triggers. If more than one trigger is configured the result fires for a pod
+// when any of them fires.
+func New(c conf.Triggers, sources Sources) Trigger {
+	var triggers Any
+	if c.Resources != nil {
+		triggers = append(triggers, newResourceTrigger(*c.Resources, sources.Metrics))
+	}
+
+	if c.Prometheus != nil {
+		triggers = append(triggers, newPrometheusTrigger(*c.Prometheus, sources.Prometheus))
+	}
+
+	if c.Logs != nil {
+		triggers = append(triggers, newLogTrigger(*c.Logs, sources.Logs))
+	}
+
+	if c.Probe != nil {
+		triggers = append(triggers, newProbeTrigger(*c.Probe))
+	}
+
+	if len(triggers) == 1 {
+		return triggers[0]
+	}
+
+	return triggers
+}
+
+// Any combines triggers into one that fires for a pod when any of them fires.
+type Any []Trigger
+
+// Fired implements Trigger. Every trigger is evaluated so that each keeps its state current. The reasons of every
+// trigger that fired for a pod are joined in order.
+func (a Any) Fired(pods []v1.Pod, now time.Time) map[string]string {
+	reasons := map[string][]string{}
+	for _, t := range a {
+		for key, reason := range t.Fired(pods, now) {
+			reasons[key] = append(reasons[key], reason)
+		}
+	}
+
+	out := make(map[string]string, len(reasons))
+	for key, r := range reasons {
+		out[key] = strings.Join(r, "; ")
+	}
+
+	return out
+}
+
+// sustained tracks for how long a condition has held per pod.
+type sustained struct {
+	dur   time.Duration
+	since map[string]time.Time
+}
+
+func newSustained(dur time.Duration) sustained {
+	return sustained{
+		dur:   dur,
+		since: map[string]time.Time{},
+	}
+}
+
+// observe records whether the condition holds for the pod with the passed key at now, and reports whether it has held
+// for at least the sustained duration.
+func (s *sustained) observe(key string, holds bool, now time.Time) bool {
+	if !holds {
+		delete(s.since, key)
+		return false
+	}
+
+	since, ok := s.since[key]
+	if !ok {
+		since = now
+		s.since[key] = now
+	}
+
+	return now.Sub(since) >= s.dur
+}
+
+// prune forgets pods that are not in the passed set of keys, e.g. because they no longer exist.
+func (s *sustained) prune(keys map[string]bool) {
+	for key := range s.since {
+		if !keys[key] {
+			delete(s.since, key)
+		}
+	}
+}